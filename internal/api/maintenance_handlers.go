@@ -0,0 +1,105 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"go-sentinel/internal/db"
+	"go-sentinel/internal/models"
+)
+
+func (s *Server) handleGetMaintenance(w http.ResponseWriter, r *http.Request) {
+	windows, err := db.GetMaintenanceWindows(r.Context(), s.DB)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(windows)
+}
+
+func (s *Server) handlePostMaintenance(w http.ResponseWriter, r *http.Request) {
+	mw, ok := s.decodeMaintenanceWindow(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := db.CreateMaintenanceWindow(r.Context(), s.DB, mw)
+	if err != nil {
+		http.Error(w, "Failed to create maintenance window", http.StatusInternalServerError)
+		return
+	}
+	mw.ID = id
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(mw)
+}
+
+func (s *Server) handlePutMaintenance(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid id parameter", http.StatusBadRequest)
+		return
+	}
+
+	mw, ok := s.decodeMaintenanceWindow(w, r)
+	if !ok {
+		return
+	}
+	mw.ID = id
+
+	found, err := db.UpdateMaintenanceWindow(r.Context(), s.DB, mw)
+	if err != nil {
+		http.Error(w, "Failed to update maintenance window", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "Maintenance window not found", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(mw)
+}
+
+// decodeMaintenanceWindow reads and validates a window from the request,
+// writing the error response itself when it fails.
+func (s *Server) decodeMaintenanceWindow(w http.ResponseWriter, r *http.Request) (models.MaintenanceWindow, bool) {
+	var mw models.MaintenanceWindow
+	if err := json.NewDecoder(r.Body).Decode(&mw); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return mw, false
+	}
+	if err := mw.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return mw, false
+	}
+
+	for _, monitorID := range mw.MonitorIDs {
+		m, err := db.GetMonitor(r.Context(), s.DB, monitorID)
+		if err != nil {
+			http.Error(w, "Database error", http.StatusInternalServerError)
+			return mw, false
+		}
+		if m == nil {
+			http.Error(w, fmt.Sprintf("monitor %d not found", monitorID), http.StatusBadRequest)
+			return mw, false
+		}
+	}
+	return mw, true
+}
+
+func (s *Server) handleDeleteMaintenance(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid id parameter", http.StatusBadRequest)
+		return
+	}
+
+	if err := db.DeleteMaintenanceWindow(r.Context(), s.DB, id); err != nil {
+		http.Error(w, "Failed to delete maintenance window", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -1,12 +1,21 @@
 package api
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"io"
+	"log"
 	"net/http"
 	"strconv"
+	"time"
 
 	"go-sentinel/internal/db"
 	"go-sentinel/internal/models"
+	"go-sentinel/internal/service/checker"
+	"go-sentinel/internal/service/monitor"
 )
 
 func (s *Server) handleGetMonitors(w http.ResponseWriter, r *http.Request) {
@@ -19,7 +28,7 @@ func (s *Server) handleGetMonitors(w http.ResponseWriter, r *http.Request) {
 
 	if !s.isAdmin(r) {
 		for i := range monitors {
-			monitors[i].URL = ""
+			monitors[i] = publicMonitor(monitors[i])
 		}
 	}
 
@@ -27,6 +36,26 @@ func (s *Server) handleGetMonitors(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(monitors)
 }
 
+// publicMonitor keeps only what a status page shows. Anything naming the
+// target or describing how it is checked is left out, so new settings stay
+// hidden unless added here.
+func publicMonitor(m models.Monitor) models.Monitor {
+	return models.Monitor{
+		ID:                  m.ID,
+		Name:                m.Name,
+		Type:                m.Type,
+		Interval:            m.Interval,
+		ParentID:            m.ParentID,
+		LastPushAt:          m.LastPushAt,
+		LastCheckedAt:       m.LastCheckedAt,
+		Active:              m.Active,
+		Status:              m.Status,
+		StatusSince:         m.StatusSince,
+		ConsecutiveFailures: m.ConsecutiveFailures,
+		Flapping:            m.Flapping,
+	}
+}
+
 func (s *Server) handlePostMonitor(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	var m models.Monitor
@@ -40,6 +69,33 @@ func (s *Server) handlePostMonitor(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := checker.ValidateTarget(m); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if problem, err := s.validateParent(ctx, m); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	} else if problem != "" {
+		http.Error(w, problem, http.StatusBadRequest)
+		return
+	}
+
+	m.Active = true
+	m.Status, m.StatusSince, m.ConsecutiveFailures, m.Flapping = "", nil, 0, false
+	m.PushToken, m.LastPushAt = "", nil
+	if m.Type == models.MonitorTypePush {
+		token, err := newPushToken()
+		if err != nil {
+			http.Error(w, "Failed to create monitor", http.StatusInternalServerError)
+			return
+		}
+		// The first heartbeat is due one interval plus grace after creation.
+		now := time.Now()
+		m.PushToken, m.LastPushAt = token, &now
+	}
+
 	id, err := db.CreateMonitor(ctx, s.DB, m)
 	if err != nil {
 		http.Error(w, "Failed to create monitor", http.StatusInternalServerError)
@@ -47,37 +103,145 @@ func (s *Server) handlePostMonitor(w http.ResponseWriter, r *http.Request) {
 	}
 
 	m.ID = id
+	s.Worker.Notify(monitor.Event{Kind: monitor.EventCreated, MonitorID: id})
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(m)
 }
 
+// handlePutMonitor applies the fields present in the request body on top of
+// the stored monitor, so clients that only know a subset of the settings do
+// not reset the rest.
 func (s *Server) handlePutMonitor(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	var m models.Monitor
-	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
-	if m.ID == 0 {
+
+	var fields map[string]json.RawMessage
+	var ref struct {
+		ID int64 `json:"id"`
+	}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if err := json.Unmarshal(body, &ref); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if ref.ID == 0 {
 		http.Error(w, "Monitor ID required", http.StatusBadRequest)
 		return
 	}
 
+	existing, err := db.GetMonitor(ctx, s.DB, ref.ID)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if existing == nil {
+		http.Error(w, "Monitor not found", http.StatusNotFound)
+		return
+	}
+
+	m := *existing
+	// Unmarshalling merges into an existing map, so start from scratch when
+	// the request replaces the headers.
+	if _, ok := fields["headers"]; ok {
+		m.Headers = nil
+	}
+	if err := json.Unmarshal(body, &m); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	m.ID = existing.ID
+	m.PushToken = existing.PushToken
+	m.LastPushAt = existing.LastPushAt
+	m.LastCheckedAt = existing.LastCheckedAt
+	m.Active = existing.Active
+	m.Status = existing.Status
+	m.StatusSince = existing.StatusSince
+	m.ConsecutiveFailures = existing.ConsecutiveFailures
+	m.Flapping = existing.Flapping
+
 	if err := m.Validate(); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
+	if err := checker.ValidateTarget(m); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if problem, err := s.validateParent(ctx, m); err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	} else if problem != "" {
+		http.Error(w, problem, http.StatusBadRequest)
+		return
+	}
+
+	becamePush := m.Type == models.MonitorTypePush && m.PushToken == ""
+	if m.Type != models.MonitorTypePush {
+		m.PushToken = ""
+	} else if becamePush {
+		if m.PushToken, err = newPushToken(); err != nil {
+			http.Error(w, "Failed to update monitor", http.StatusInternalServerError)
+			return
+		}
+	}
+
 	if err := db.UpdateMonitor(ctx, s.DB, m); err != nil {
 		http.Error(w, "Failed to update monitor", http.StatusInternalServerError)
 		return
 	}
+	// As for a new push monitor, the first heartbeat is due one interval
+	// plus grace from now.
+	if becamePush {
+		if err := db.RecordHeartbeat(ctx, s.DB, m.ID); err != nil {
+			http.Error(w, "Failed to update monitor", http.StatusInternalServerError)
+			return
+		}
+		now := time.Now()
+		m.LastPushAt = &now
+	}
+	s.Worker.Notify(monitor.Event{Kind: monitor.EventUpdated, MonitorID: m.ID})
 
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(m)
 }
 
+// maxDependencyDepth bounds how far validateParent follows parent links.
+const maxDependencyDepth = 16
+
+// validateParent returns a client-facing problem when m's parent does not
+// exist or the dependency chain would loop back to m.
+func (s *Server) validateParent(ctx context.Context, m models.Monitor) (string, error) {
+	id := m.ParentID
+	for depth := 0; id != 0; depth++ {
+		if id == m.ID || depth >= maxDependencyDepth {
+			return "parent_id would create a dependency loop", nil
+		}
+		parent, err := db.GetMonitor(ctx, s.DB, id)
+		if err != nil {
+			return "", err
+		}
+		if parent == nil {
+			if depth == 0 {
+				return "parent monitor not found", nil
+			}
+			break
+		}
+		id = parent.ParentID
+	}
+	return "", nil
+}
+
 func (s *Server) handleDeleteMonitor(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	idStr := r.PathValue("id")
@@ -96,6 +260,7 @@ func (s *Server) handleDeleteMonitor(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to delete monitor", http.StatusInternalServerError)
 		return
 	}
+	s.Worker.Notify(monitor.Event{Kind: monitor.EventDeleted, MonitorID: int64(id)})
 
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -135,3 +300,183 @@ func (s *Server) handleAllHistory(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(stats)
 }
+
+func (s *Server) handlePauseMonitor(w http.ResponseWriter, r *http.Request) {
+	s.setMonitorActive(w, r, false)
+}
+
+func (s *Server) handleResumeMonitor(w http.ResponseWriter, r *http.Request) {
+	s.setMonitorActive(w, r, true)
+}
+
+// setMonitorActive pauses or resumes a monitor. Paused monitors keep their
+// history but are not checked, so they drop out of uptime until resumed.
+func (s *Server) setMonitorActive(w http.ResponseWriter, r *http.Request, active bool) {
+	ctx := r.Context()
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid id parameter", http.StatusBadRequest)
+		return
+	}
+
+	found, err := db.SetMonitorActive(ctx, s.DB, id, active)
+	if err != nil {
+		http.Error(w, "Failed to update monitor", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "Monitor not found", http.StatusNotFound)
+		return
+	}
+	s.Worker.Notify(monitor.Event{Kind: monitor.EventUpdated, MonitorID: id})
+
+	m, err := db.GetMonitor(ctx, s.DB, id)
+	if err != nil || m == nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m)
+}
+
+func (s *Server) handleGetCertificate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid id parameter", http.StatusBadRequest)
+		return
+	}
+
+	cert, err := db.GetCertificate(ctx, s.DB, id)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if cert == nil {
+		http.Error(w, "No certificate recorded for this monitor", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cert)
+}
+
+// checkResponse is the full outcome of an on-demand check.
+type checkResponse struct {
+	models.Check
+	StatusCode  int                 `json:"status_code,omitempty"`
+	Certificate *models.Certificate `json:"certificate,omitempty"`
+}
+
+// handleCheckMonitor runs a saved monitor's check right away through the
+// worker, so the result is stored and alerts fire as for a scheduled check.
+func (s *Server) handleCheckMonitor(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid id parameter", http.StatusBadRequest)
+		return
+	}
+	if s.Worker == nil {
+		http.Error(w, "Checks are not running", http.StatusServiceUnavailable)
+		return
+	}
+
+	m, err := db.GetMonitor(r.Context(), s.DB, id)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if m == nil {
+		http.Error(w, "Monitor not found", http.StatusNotFound)
+		return
+	}
+	// A paused monitor is out of uptime; a check would count towards it.
+	if !m.Active {
+		http.Error(w, "Monitor is paused", http.StatusConflict)
+		return
+	}
+
+	startCheck(w, *m)
+	check, cert, err := s.Worker.CheckNow(r.Context(), *m)
+	switch {
+	case errors.Is(err, monitor.ErrBusy):
+		http.Error(w, "Monitor is busy, try again later", http.StatusServiceUnavailable)
+		return
+	case err != nil:
+		http.Error(w, "Checks are not running", http.StatusServiceUnavailable)
+		return
+	}
+	writeCheckResponse(w, check, cert)
+}
+
+// handleTestMonitor checks a monitor configuration without saving it, so
+// settings can be tried out before the monitor is created or updated.
+func (s *Server) handleTestMonitor(w http.ResponseWriter, r *http.Request) {
+	var m models.Monitor
+	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := m.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if m.Type == models.MonitorTypePush {
+		http.Error(w, "Push monitors cannot be tested", http.StatusBadRequest)
+		return
+	}
+	if err := checker.ValidateTarget(m); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	startCheck(w, m)
+	check, cert := monitor.DryRun(r.Context(), m)
+	writeCheckResponse(w, check, cert)
+}
+
+// startCheck prepares a request to wait for a check by pushing the write
+// deadline past the longest a manual check can take: waiting for a free
+// slot and then running, each for up to the monitor's timeout.
+func startCheck(w http.ResponseWriter, m models.Monitor) {
+	deadline := time.Now().Add(2*m.TimeoutDuration() + 5*time.Second)
+	if err := http.NewResponseController(w).SetWriteDeadline(deadline); err != nil {
+		log.Printf("API: cannot extend the write deadline for checking %s: %v", m.Name, err)
+	}
+}
+
+func writeCheckResponse(w http.ResponseWriter, check models.Check, cert *models.Certificate) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(checkResponse{Check: check, StatusCode: check.StatusCode, Certificate: cert})
+}
+
+// handlePush records a heartbeat for the push monitor owning the token.
+func (s *Server) handlePush(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	m, err := db.GetMonitorByPushToken(ctx, s.DB, r.PathValue("token"))
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if m == nil {
+		http.Error(w, "Unknown push token", http.StatusNotFound)
+		return
+	}
+
+	if err := db.RecordHeartbeat(ctx, s.DB, m.ID); err != nil {
+		http.Error(w, "Failed to record heartbeat", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+func newPushToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -12,10 +12,13 @@ import (
 	"net/http"
 	"strings"
 	"time"
+
+	"go-sentinel/internal/service/monitor"
 )
 
 type Server struct {
 	DB         *sql.DB
+	Worker     *monitor.Worker
 	mux        *http.ServeMux
 	AdminToken string
 	Version    string
@@ -65,10 +68,19 @@ func (s *Server) registerRoutes() {
 	s.mux.HandleFunc("POST /monitors", s.limitRequestSize(s.adminOnly(s.handlePostMonitor)))
 	s.mux.HandleFunc("PUT /monitors", s.limitRequestSize(s.adminOnly(s.handlePutMonitor)))
 	s.mux.HandleFunc("DELETE /monitors/{id}", s.adminOnly(s.handleDeleteMonitor))
+	s.mux.HandleFunc("POST /monitors/test", s.limitRequestSize(s.adminOnly(s.handleTestMonitor)))
+	s.mux.HandleFunc("POST /monitors/{id}/check", s.adminOnly(s.handleCheckMonitor))
+	s.mux.HandleFunc("POST /monitors/{id}/pause", s.adminOnly(s.handlePauseMonitor))
+	s.mux.HandleFunc("POST /monitors/{id}/resume", s.adminOnly(s.handleResumeMonitor))
+	s.mux.HandleFunc("GET /monitors/{id}/certificate", s.adminOnly(s.handleGetCertificate))
+
+	s.mux.HandleFunc("POST /push/{token}", s.limitRequestSize(s.handlePush))
+	s.mux.HandleFunc("GET /push/{token}", s.handlePush)
 
 	s.mux.HandleFunc("GET /checks", s.handleChecks)
 	s.mux.HandleFunc("GET /version", s.handleVersion)
 	s.mux.HandleFunc("GET /health", s.handleHealth)
+	s.mux.HandleFunc("GET /metrics", s.adminOnly(s.handleMetrics))
 	s.mux.HandleFunc("POST /verify-token", s.handleVerifyToken)
 	s.mux.HandleFunc("GET /history", s.handleAllHistory)
 	s.mux.HandleFunc("GET /history/{id}", s.handleHistory)
@@ -77,10 +89,16 @@ func (s *Server) registerRoutes() {
 	s.mux.HandleFunc("POST /incidents", s.limitRequestSize(s.adminOnly(s.handlePostIncident)))
 	s.mux.HandleFunc("DELETE /incidents/{id}", s.adminOnly(s.handleDeleteIncident))
 
+	s.mux.HandleFunc("GET /maintenance", s.handleGetMaintenance)
+	s.mux.HandleFunc("POST /maintenance", s.limitRequestSize(s.adminOnly(s.handlePostMaintenance)))
+	s.mux.HandleFunc("PUT /maintenance/{id}", s.limitRequestSize(s.adminOnly(s.handlePutMaintenance)))
+	s.mux.HandleFunc("DELETE /maintenance/{id}", s.adminOnly(s.handleDeleteMaintenance))
+
 	s.mux.HandleFunc("GET /webhooks", s.adminOnly(s.handleGetWebhooks))
 	s.mux.HandleFunc("POST /webhooks", s.limitRequestSize(s.adminOnly(s.handlePostWebhook)))
 	s.mux.HandleFunc("PUT /webhooks/{id}", s.limitRequestSize(s.adminOnly(s.handlePutWebhook)))
 	s.mux.HandleFunc("DELETE /webhooks/{id}", s.adminOnly(s.handleDeleteWebhook))
+	s.mux.HandleFunc("GET /webhooks/{id}/deliveries", s.adminOnly(s.handleGetWebhookDeliveries))
 }
 
 func (s *Server) RegisterFrontend(staticFS fs.FS) {
@@ -109,6 +127,12 @@ func (g gzipResponseWriter) Write(b []byte) (int, error) {
 	return g.Writer.Write(b)
 }
 
+// Unwrap lets http.ResponseController reach the underlying writer, so
+// handlers can still adjust deadlines on compressed responses.
+func (g gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
+}
+
 func (s *Server) gzipMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
@@ -31,6 +31,16 @@ func (s *Server) handleChecks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Error messages and assertions can reveal hidden monitor targets.
+	if !s.isAdmin(r) {
+		for _, checks := range checksMap {
+			for i := range checks {
+				checks[i].Assertion = ""
+				checks[i].ErrorMessage = ""
+			}
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(checksMap)
 }
@@ -40,6 +50,11 @@ func (s *Server) handleVersion(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(map[string]string{"version": s.Version})
 }
 
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.Worker.Stats())
+}
+
 func (s *Server) handleVerifyToken(w http.ResponseWriter, r *http.Request) {
 	if !s.isAdmin(r) {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -7,6 +7,7 @@ import (
 
 	"go-sentinel/internal/db"
 	"go-sentinel/internal/models"
+	"go-sentinel/internal/service/notifier"
 )
 
 func (s *Server) handleGetWebhooks(w http.ResponseWriter, r *http.Request) {
@@ -29,6 +30,10 @@ func (s *Server) handlePostWebhook(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if _, err := notifier.ParseTemplate(wh.Settings["body"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	wh.Enabled = true
 
 	id, err := db.CreateWebhook(r.Context(), s.DB, wh)
@@ -56,12 +61,31 @@ func (s *Server) handlePutWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	wh.ID = id
+
+	existing, err := db.GetWebhook(r.Context(), s.DB, id)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if existing == nil {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+	// Clients that predate channel types only send name, url and enabled.
+	if wh.Type == "" {
+		wh.Type, wh.Settings, wh.Headers = existing.Type, existing.Settings, existing.Headers
+	}
+
 	if err := wh.Validate(); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	if _, err := notifier.ParseTemplate(wh.Settings["body"]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	if err := db.UpdateWebhook(r.Context(), s.DB, id, wh.Name, wh.URL, wh.Enabled); err != nil {
+	if err := db.UpdateWebhook(r.Context(), s.DB, wh); err != nil {
 		http.Error(w, "Failed to update webhook", http.StatusInternalServerError)
 		return
 	}
@@ -82,3 +106,33 @@ func (s *Server) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// deliveryHistory is how many recent deliveries the delivery log shows.
+const deliveryHistory = 50
+
+func (s *Server) handleGetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid id parameter", http.StatusBadRequest)
+		return
+	}
+
+	wh, err := db.GetWebhook(r.Context(), s.DB, id)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	if wh == nil {
+		http.Error(w, "Webhook not found", http.StatusNotFound)
+		return
+	}
+
+	deliveries, err := db.GetDeliveries(r.Context(), s.DB, id, deliveryHistory)
+	if err != nil {
+		http.Error(w, "Database error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
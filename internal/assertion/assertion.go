@@ -0,0 +1,226 @@
+// Package assertion parses and evaluates JSON response assertions such as
+// `$.status == "ok"` or `$.queues[0].depth < 1000`.
+package assertion
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var operators = []string{"==", "!=", "<=", ">=", "<", ">"}
+
+type Assertion struct {
+	Raw      string
+	path     []any // string keys and int indexes
+	operator string
+	expected any
+}
+
+// Parse reads an assertion of the form `<path> <operator> <JSON literal>`.
+// Paths start at `$` and use `.key`, `["key"]` or `[index]` steps. `<`,
+// `<=`, `>` and `>=` order numbers numerically and strings byte by byte, so
+// they do not order version strings such as "1.10.0" and "1.9.0".
+func Parse(raw string) (Assertion, error) {
+	a := Assertion{Raw: raw}
+	s := strings.TrimSpace(raw)
+	if !strings.HasPrefix(s, "$") {
+		return a, errors.New("path must start with $")
+	}
+
+	i := 1
+	for i < len(s) && (s[i] == '.' || s[i] == '[') {
+		if s[i] == '.' {
+			j := i + 1
+			for j < len(s) && isKeyChar(s[j]) {
+				j++
+			}
+			if j == i+1 {
+				return a, fmt.Errorf("empty key at position %d", i)
+			}
+			a.path = append(a.path, s[i+1:j])
+			i = j
+			continue
+		}
+
+		end := strings.IndexByte(s[i:], ']')
+		if end < 0 {
+			return a, errors.New("unterminated [")
+		}
+		inner := s[i+1 : i+end]
+		if key, err := strconv.Unquote(inner); err == nil {
+			a.path = append(a.path, key)
+		} else if idx, err := strconv.Atoi(inner); err == nil && idx >= 0 {
+			a.path = append(a.path, idx)
+		} else {
+			return a, fmt.Errorf("invalid index %q", inner)
+		}
+		i += end + 1
+	}
+
+	rest := strings.TrimSpace(s[i:])
+	for _, op := range operators {
+		if strings.HasPrefix(rest, op) {
+			a.operator = op
+			rest = strings.TrimSpace(rest[len(op):])
+			break
+		}
+	}
+	if a.operator == "" {
+		return a, fmt.Errorf("expected one of %s after path", strings.Join(operators, " "))
+	}
+
+	if err := json.Unmarshal([]byte(rest), &a.expected); err != nil {
+		return a, errors.New("value must be a JSON literal (string, number, true, false or null)")
+	}
+	switch a.expected.(type) {
+	case map[string]any, []any:
+		return a, errors.New("value must be a JSON literal (string, number, true, false or null)")
+	}
+	if a.operator != "==" && a.operator != "!=" {
+		switch a.expected.(type) {
+		case float64, string:
+		default:
+			return a, fmt.Errorf("operator %s needs a number or string value", a.operator)
+		}
+	}
+
+	return a, nil
+}
+
+// ParseAll parses every assertion, reporting the first invalid one.
+func ParseAll(raw []string) ([]Assertion, error) {
+	parsed := make([]Assertion, 0, len(raw))
+	for _, r := range raw {
+		a, err := Parse(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid assertion %q: %w", r, err)
+		}
+		parsed = append(parsed, a)
+	}
+	return parsed, nil
+}
+
+// Evaluate checks the assertion against a decoded JSON document. On failure
+// it returns a short description of what was found instead.
+func (a Assertion) Evaluate(doc any) (bool, string) {
+	actual, ok := lookup(doc, a.path)
+	if !ok {
+		return false, "path not found"
+	}
+
+	var pass bool
+	switch a.operator {
+	case "==":
+		pass = reflect.DeepEqual(actual, a.expected)
+	case "!=":
+		pass = !reflect.DeepEqual(actual, a.expected)
+	default:
+		cmp, comparable := compare(actual, a.expected)
+		if !comparable {
+			return false, "got " + describe(actual)
+		}
+		switch a.operator {
+		case "<":
+			pass = cmp < 0
+		case "<=":
+			pass = cmp <= 0
+		case ">":
+			pass = cmp > 0
+		case ">=":
+			pass = cmp >= 0
+		}
+	}
+
+	if pass {
+		return true, ""
+	}
+	return false, "got " + describe(actual)
+}
+
+// EvaluateBody decodes body as JSON and evaluates each assertion in order,
+// returning a description of the first one that fails.
+func EvaluateBody(body []byte, assertions []Assertion) (bool, string) {
+	if len(assertions) == 0 {
+		return true, ""
+	}
+
+	var doc any
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return false, "response body is not valid JSON"
+	}
+
+	for _, a := range assertions {
+		if ok, detail := a.Evaluate(doc); !ok {
+			return false, fmt.Sprintf("%s (%s)", a.Raw, detail)
+		}
+	}
+	return true, ""
+}
+
+func lookup(doc any, path []any) (any, bool) {
+	current := doc
+	for _, step := range path {
+		switch key := step.(type) {
+		case string:
+			obj, ok := current.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			if current, ok = obj[key]; !ok {
+				return nil, false
+			}
+		case int:
+			arr, ok := current.([]any)
+			if !ok || key >= len(arr) {
+				return nil, false
+			}
+			current = arr[key]
+		}
+	}
+	return current, true
+}
+
+// compare orders actual against expected if both are numbers or both are
+// strings. Strings compare lexicographically.
+func compare(actual, expected any) (int, bool) {
+	switch e := expected.(type) {
+	case float64:
+		a, ok := actual.(float64)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case a < e:
+			return -1, true
+		case a > e:
+			return 1, true
+		}
+		return 0, true
+	case string:
+		a, ok := actual.(string)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(a, e), true
+	}
+	return 0, false
+}
+
+func describe(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	if len(b) > 100 {
+		return string(b[:100]) + "…"
+	}
+	return string(b)
+}
+
+func isKeyChar(c byte) bool {
+	return c == '_' || c == '-' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
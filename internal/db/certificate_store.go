@@ -0,0 +1,67 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"go-sentinel/internal/models"
+	"strings"
+	"time"
+)
+
+// SaveCertificate stores the latest certificate seen for a monitor. The
+// expiry warning marker is reset whenever a different certificate (by
+// expiry date) is presented, e.g. after a renewal.
+func SaveCertificate(ctx context.Context, db *sql.DB, cert models.Certificate) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO certificates (monitor_id, subject, issuer, sans, not_before, not_after, checked_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(monitor_id) DO UPDATE SET
+			subject = excluded.subject,
+			issuer = excluded.issuer,
+			sans = excluded.sans,
+			not_before = excluded.not_before,
+			last_warned_days = CASE WHEN not_after = excluded.not_after THEN last_warned_days ELSE 0 END,
+			not_after = excluded.not_after,
+			checked_at = excluded.checked_at`,
+		cert.MonitorID, cert.Subject, cert.Issuer, strings.Join(cert.SANs, ","),
+		cert.NotBefore.UTC(), cert.NotAfter.UTC(), time.Now().UTC(),
+	)
+	return err
+}
+
+func GetCertificate(ctx context.Context, db *sql.DB, monitorID int64) (*models.Certificate, error) {
+	row := db.QueryRowContext(ctx,
+		"SELECT monitor_id, subject, issuer, sans, not_before, not_after, checked_at FROM certificates WHERE monitor_id = ?",
+		monitorID,
+	)
+
+	var c models.Certificate
+	var sans string
+	if err := row.Scan(&c.MonitorID, &c.Subject, &c.Issuer, &sans, &c.NotBefore, &c.NotAfter, &c.CheckedAt); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	c.SANs = []string{}
+	if sans != "" {
+		c.SANs = strings.Split(sans, ",")
+	}
+	return &c, nil
+}
+
+// GetCertificateWarnedDays returns the smallest days-left threshold already
+// notified for the monitor's current certificate, or 0 if none was sent.
+func GetCertificateWarnedDays(ctx context.Context, db *sql.DB, monitorID int64) (int, error) {
+	var days int
+	err := db.QueryRowContext(ctx, "SELECT last_warned_days FROM certificates WHERE monitor_id = ?", monitorID).Scan(&days)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return days, err
+}
+
+func SetCertificateWarnedDays(ctx context.Context, db *sql.DB, monitorID int64, days int) error {
+	_, err := db.ExecContext(ctx, "UPDATE certificates SET last_warned_days = ? WHERE monitor_id = ?", days, monitorID)
+	return err
+}
@@ -15,27 +15,55 @@ func SaveCheckAndUpdateStats(ctx context.Context, db *sql.DB, check models.Check
 	}
 	defer tx.Rollback()
 
+	t := check.Timings
 	_, err = tx.ExecContext(ctx,
-		"INSERT INTO checks (monitor_id, status_code, latency, is_up) VALUES (?, ?, ?, ?)",
-		check.MonitorID, check.StatusCode, check.Latency, check.IsUp,
+		`INSERT INTO checks (monitor_id, status_code, latency, is_up, assertion, error_category, error_message,
+			dns_ms, connect_ms, tls_ms, ttfb_ms, transfer_ms, maintenance)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		check.MonitorID, check.StatusCode, check.Latency, check.IsUp, check.Assertion,
+		check.ErrorCategory, check.ErrorMessage,
+		t.DNS, t.Connect, t.TLS, t.TTFB, t.Transfer, check.Maintenance,
 	)
 	if err != nil {
 		return err
 	}
 
 	date := time.Now().Format("2006-01-02")
-	upIncrement := 0
-	if check.IsUp {
+	// Checks during maintenance are kept out of the uptime ratio: they are
+	// counted in total_count and maintenance_count but never in up_count.
+	upIncrement, maintenanceIncrement := 0, 0
+	if check.Maintenance {
+		maintenanceIncrement = 1
+	} else if check.IsUp {
 		upIncrement = 1
 	}
+	// Phases a check did not go through, such as DNS on a reused
+	// connection or TLS on plain HTTP, are zero and are not counted, so
+	// they do not drag the phase averages down.
 	_, err = tx.ExecContext(ctx, `
-		INSERT INTO daily_stats (monitor_id, date, up_count, total_count, total_latency)
-		VALUES (?, ?, ?, 1, ?)
+		INSERT INTO daily_stats (monitor_id, date, up_count, total_count, total_latency,
+			total_dns, total_connect, total_tls, total_ttfb, total_transfer,
+			dns_count, connect_count, tls_count, ttfb_count, transfer_count, maintenance_count)
+		VALUES (?, ?, ?, 1, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(monitor_id, date) DO UPDATE SET
-			up_count = up_count + ?,
+			up_count = up_count + excluded.up_count,
 			total_count = total_count + 1,
-			total_latency = total_latency + ?`,
-		check.MonitorID, date, upIncrement, check.Latency, upIncrement, check.Latency,
+			total_latency = total_latency + excluded.total_latency,
+			total_dns = total_dns + excluded.total_dns,
+			total_connect = total_connect + excluded.total_connect,
+			total_tls = total_tls + excluded.total_tls,
+			total_ttfb = total_ttfb + excluded.total_ttfb,
+			total_transfer = total_transfer + excluded.total_transfer,
+			dns_count = dns_count + excluded.dns_count,
+			connect_count = connect_count + excluded.connect_count,
+			tls_count = tls_count + excluded.tls_count,
+			ttfb_count = ttfb_count + excluded.ttfb_count,
+			transfer_count = transfer_count + excluded.transfer_count,
+			maintenance_count = maintenance_count + excluded.maintenance_count`,
+		check.MonitorID, date, upIncrement, check.Latency,
+		t.DNS, t.Connect, t.TLS, t.TTFB, t.Transfer,
+		measured(t.DNS), measured(t.Connect), measured(t.TLS), measured(t.TTFB), measured(t.Transfer),
+		maintenanceIncrement,
 	)
 	if err != nil {
 		return err
@@ -44,9 +72,18 @@ func SaveCheckAndUpdateStats(ctx context.Context, db *sql.DB, check models.Check
 	return tx.Commit()
 }
 
+// measured is 1 for a phase the check went through and 0 otherwise.
+func measured(ms int64) int {
+	if ms > 0 {
+		return 1
+	}
+	return 0
+}
+
 func GetChecks(ctx context.Context, db *sql.DB, limitPerMonitor int) (map[int64][]models.Check, error) {
 	query := `
-		SELECT id, monitor_id, status_code, latency, is_up, checked_at
+		SELECT id, monitor_id, status_code, latency, is_up, assertion, error_category, error_message,
+			dns_ms, connect_ms, tls_ms, ttfb_ms, transfer_ms, maintenance, checked_at
 		FROM (
 			SELECT *, ROW_NUMBER() OVER (PARTITION BY monitor_id ORDER BY checked_at DESC) as rn
 			FROM checks
@@ -63,7 +100,9 @@ func GetChecks(ctx context.Context, db *sql.DB, limitPerMonitor int) (map[int64]
 	grouped := make(map[int64][]models.Check)
 	for rows.Next() {
 		var c models.Check
-		if err := rows.Scan(&c.ID, &c.MonitorID, &c.StatusCode, &c.Latency, &c.IsUp, &c.CheckedAt); err != nil {
+		t := &c.Timings
+		if err := rows.Scan(&c.ID, &c.MonitorID, &c.StatusCode, &c.Latency, &c.IsUp, &c.Assertion, &c.ErrorCategory, &c.ErrorMessage,
+			&t.DNS, &t.Connect, &t.TLS, &t.TTFB, &t.Transfer, &c.Maintenance, &c.CheckedAt); err != nil {
 			return nil, err
 		}
 		grouped[c.MonitorID] = append(grouped[c.MonitorID], c)
@@ -0,0 +1,206 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"go-sentinel/internal/models"
+	"strings"
+	"time"
+)
+
+// Delivery times are stored in UTC so that they compare correctly as text.
+
+const deliveryColumns = `id, webhook_id, event, monitor_id, payload, status, attempts, next_attempt_at,
+	last_status_code, last_error, created_at`
+
+// CreateDelivery queues a pending delivery. Its first attempt is leased
+// until nextAttempt, so a retry only picks it up if that attempt never
+// finished. Pending deliveries to the same channel about the same monitor
+// for any of the supersedes events are superseded, so that a stale alert is
+// never sent after a newer one.
+func CreateDelivery(ctx context.Context, db *sql.DB, d models.Delivery, nextAttempt time.Time, supersedes []string) (int64, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if len(supersedes) > 0 {
+		args := []any{models.DeliverySuperseded, d.WebhookID, d.MonitorID, models.DeliveryPending}
+		for _, event := range supersedes {
+			args = append(args, event)
+		}
+		_, err = tx.ExecContext(ctx,
+			`UPDATE deliveries SET status = ?, next_attempt_at = NULL
+			WHERE webhook_id = ? AND monitor_id = ? AND status = ? AND event IN (`+placeholders(len(supersedes))+`)`,
+			args...,
+		)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO deliveries (webhook_id, event, monitor_id, payload, status, next_attempt_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		d.WebhookID, d.Event, d.MonitorID, d.Payload, models.DeliveryPending,
+		nextAttempt.UTC(), time.Now().UTC(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// placeholders returns n comma-separated query placeholders.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// GetDueDeliveries returns up to limit pending deliveries whose next
+// attempt is due at now, oldest first.
+func GetDueDeliveries(ctx context.Context, db *sql.DB, now time.Time, limit int) ([]models.Delivery, error) {
+	rows, err := db.QueryContext(ctx,
+		"SELECT "+deliveryColumns+` FROM deliveries
+		WHERE status = ? AND next_attempt_at <= ?
+		ORDER BY next_attempt_at ASC, id ASC LIMIT ?`,
+		models.DeliveryPending, now.UTC(), limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return scanDeliveries(rows)
+}
+
+// ClaimDelivery leases a due delivery until leaseUntil so that only one
+// caller attempts it. It reports whether the claim succeeded.
+func ClaimDelivery(ctx context.Context, db *sql.DB, id int64, now, leaseUntil time.Time) (bool, error) {
+	result, err := db.ExecContext(ctx,
+		"UPDATE deliveries SET next_attempt_at = ? WHERE id = ? AND status = ? AND next_attempt_at <= ?",
+		leaseUntil.UTC(), id, models.DeliveryPending, now.UTC(),
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n == 1, err
+}
+
+// RecordDeliveryAttempt logs an attempt and moves the delivery to status.
+// nextAttempt is only kept for pending deliveries. A delivery superseded
+// while the attempt was in flight stays superseded.
+func RecordDeliveryAttempt(ctx context.Context, db *sql.DB, id int64, attempt models.DeliveryAttempt, status string, nextAttempt time.Time) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO delivery_attempts (delivery_id, attempted_at, status_code, error, duration_ms)
+		VALUES (?, ?, ?, ?, ?)`,
+		id, attempt.AttemptedAt.UTC(), attempt.StatusCode, attempt.Error, attempt.Duration,
+	)
+	if err != nil {
+		return err
+	}
+
+	var next any
+	if status == models.DeliveryPending {
+		next = nextAttempt.UTC()
+	}
+	_, err = tx.ExecContext(ctx,
+		`UPDATE deliveries SET attempts = attempts + 1, last_status_code = ?, last_error = ?,
+			status = CASE WHEN status = ? THEN ? ELSE status END,
+			next_attempt_at = CASE WHEN status = ? THEN ? ELSE NULL END
+		WHERE id = ?`,
+		attempt.StatusCode, attempt.Error, models.DeliveryPending, status, models.DeliveryPending, next, id,
+	)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetDeliveries returns a channel's latest deliveries, newest first, with
+// their attempts.
+func GetDeliveries(ctx context.Context, db *sql.DB, webhookID int64, limit int) ([]models.Delivery, error) {
+	rows, err := db.QueryContext(ctx,
+		"SELECT "+deliveryColumns+" FROM deliveries WHERE webhook_id = ? ORDER BY id DESC LIMIT ?",
+		webhookID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	deliveries, err := scanDeliveries(rows)
+	if err != nil || len(deliveries) == 0 {
+		return deliveries, err
+	}
+
+	byID := make(map[int64]*models.Delivery, len(deliveries))
+	for i := range deliveries {
+		byID[deliveries[i].ID] = &deliveries[i]
+	}
+	rows, err = db.QueryContext(ctx, `
+		SELECT a.delivery_id, a.attempted_at, a.status_code, a.error, a.duration_ms
+		FROM delivery_attempts a
+		JOIN (SELECT id FROM deliveries WHERE webhook_id = ? ORDER BY id DESC LIMIT ?) d ON d.id = a.delivery_id
+		ORDER BY a.id ASC`,
+		webhookID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var deliveryID int64
+		var a models.DeliveryAttempt
+		if err := rows.Scan(&deliveryID, &a.AttemptedAt, &a.StatusCode, &a.Error, &a.Duration); err != nil {
+			return nil, err
+		}
+		if d, ok := byID[deliveryID]; ok {
+			d.Attempts = append(d.Attempts, a)
+		}
+	}
+	return deliveries, rows.Err()
+}
+
+func scanDeliveries(rows *sql.Rows) ([]models.Delivery, error) {
+	defer rows.Close()
+
+	deliveries := []models.Delivery{}
+	for rows.Next() {
+		var d models.Delivery
+		var next sql.NullTime
+		if err := rows.Scan(&d.ID, &d.WebhookID, &d.Event, &d.MonitorID, &d.Payload, &d.Status, &d.AttemptCount, &next,
+			&d.LastStatusCode, &d.LastError, &d.CreatedAt); err != nil {
+			return nil, err
+		}
+		if next.Valid {
+			d.NextAttemptAt = &next.Time
+		}
+		d.Attempts = []models.DeliveryAttempt{}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// CleanupOldDeliveries removes finished deliveries older than days days.
+// Pending deliveries are kept until they finish.
+func CleanupOldDeliveries(ctx context.Context, db *sql.DB, days int) (int64, error) {
+	cutoff := time.Now().AddDate(0, 0, -days).UTC()
+	result, err := db.ExecContext(ctx,
+		"DELETE FROM deliveries WHERE status != ? AND created_at < ?",
+		models.DeliveryPending, cutoff,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
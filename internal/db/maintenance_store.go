@@ -0,0 +1,180 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"go-sentinel/internal/models"
+	"strings"
+)
+
+const maintenanceColumns = "id, title, timezone, starts_at, ends_at, weekdays, start_time, duration"
+
+func CreateMaintenanceWindow(ctx context.Context, db *sql.DB, mw models.MaintenanceWindow) (int64, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`INSERT INTO maintenance_windows (title, timezone, starts_at, ends_at, weekdays, start_time, duration)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		mw.Title, mw.Timezone, mw.StartsAt, mw.EndsAt, jsonText(weekdays(mw)), mw.StartTime, mw.Duration,
+	)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := linkMaintenanceMonitors(ctx, tx, id, mw.MonitorIDs); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// UpdateMaintenanceWindow replaces a window and its monitor links. It
+// reports false if the window does not exist.
+func UpdateMaintenanceWindow(ctx context.Context, db *sql.DB, mw models.MaintenanceWindow) (bool, error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx,
+		`UPDATE maintenance_windows SET title = ?, timezone = ?, starts_at = ?, ends_at = ?,
+			weekdays = ?, start_time = ?, duration = ?
+		WHERE id = ?`,
+		mw.Title, mw.Timezone, mw.StartsAt, mw.EndsAt, jsonText(weekdays(mw)), mw.StartTime, mw.Duration, mw.ID,
+	)
+	if err != nil {
+		return false, err
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM maintenance_monitors WHERE window_id = ?", mw.ID); err != nil {
+		return false, err
+	}
+	if err := linkMaintenanceMonitors(ctx, tx, mw.ID, mw.MonitorIDs); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+func linkMaintenanceMonitors(ctx context.Context, tx *sql.Tx, windowID int64, monitorIDs []int64) error {
+	for _, monitorID := range monitorIDs {
+		if _, err := tx.ExecContext(ctx,
+			"INSERT OR IGNORE INTO maintenance_monitors (window_id, monitor_id) VALUES (?, ?)",
+			windowID, monitorID,
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// weekdays keeps one-off windows stored as an empty list rather than null.
+func weekdays(mw models.MaintenanceWindow) []int {
+	if mw.Weekdays == nil {
+		return []int{}
+	}
+	return mw.Weekdays
+}
+
+func GetMaintenanceWindows(ctx context.Context, db *sql.DB) ([]models.MaintenanceWindow, error) {
+	return queryMaintenanceWindows(ctx, db,
+		"SELECT "+maintenanceColumns+" FROM maintenance_windows ORDER BY id ASC")
+}
+
+// GetMaintenanceWindowsForMonitor returns the windows linked to a monitor.
+func GetMaintenanceWindowsForMonitor(ctx context.Context, db *sql.DB, monitorID int64) ([]models.MaintenanceWindow, error) {
+	return queryMaintenanceWindows(ctx, db,
+		"SELECT "+prefixColumns("mw", maintenanceColumns)+` FROM maintenance_windows mw
+		JOIN maintenance_monitors mm ON mm.window_id = mw.id
+		WHERE mm.monitor_id = ?
+		ORDER BY mw.id ASC`,
+		monitorID,
+	)
+}
+
+func queryMaintenanceWindows(ctx context.Context, db *sql.DB, query string, args ...any) ([]models.MaintenanceWindow, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	windows := []models.MaintenanceWindow{}
+	byID := make(map[int64]int)
+	for rows.Next() {
+		var mw models.MaintenanceWindow
+		var startsAt, endsAt sql.NullTime
+		var days string
+		if err := rows.Scan(&mw.ID, &mw.Title, &mw.Timezone, &startsAt, &endsAt, &days, &mw.StartTime, &mw.Duration); err != nil {
+			return nil, err
+		}
+		if startsAt.Valid {
+			mw.StartsAt = &startsAt.Time
+		}
+		if endsAt.Valid {
+			mw.EndsAt = &endsAt.Time
+		}
+		if err := json.Unmarshal([]byte(days), &mw.Weekdays); err != nil {
+			return nil, err
+		}
+		mw.MonitorIDs = []int64{}
+		byID[mw.ID] = len(windows)
+		windows = append(windows, mw)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	if len(windows) == 0 {
+		return windows, nil
+	}
+
+	// Only the links of the windows found, so a per-monitor lookup does not
+	// read every link.
+	ids := make([]any, len(windows))
+	for i, mw := range windows {
+		ids[i] = mw.ID
+	}
+	links, err := db.QueryContext(ctx,
+		"SELECT window_id, monitor_id FROM maintenance_monitors WHERE window_id IN ("+placeholders(len(ids))+") ORDER BY monitor_id ASC",
+		ids...,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer links.Close()
+	for links.Next() {
+		var windowID, monitorID int64
+		if err := links.Scan(&windowID, &monitorID); err != nil {
+			return nil, err
+		}
+		if i, ok := byID[windowID]; ok {
+			windows[i].MonitorIDs = append(windows[i].MonitorIDs, monitorID)
+		}
+	}
+	return windows, links.Err()
+}
+
+func prefixColumns(alias, columns string) string {
+	parts := strings.Split(columns, ", ")
+	for i, c := range parts {
+		parts[i] = alias + "." + c
+	}
+	return strings.Join(parts, ", ")
+}
+
+func DeleteMaintenanceWindow(ctx context.Context, db *sql.DB, id int64) error {
+	_, err := db.ExecContext(ctx, "DELETE FROM maintenance_windows WHERE id = ?", id)
+	return err
+}
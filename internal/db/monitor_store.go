@@ -3,14 +3,47 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
 	"go-sentinel/internal/models"
+	"strings"
 	"time"
 )
 
+// monitorSettingColumns are the user-editable configuration columns, in the
+// same order as the values returned by monitorSettingArgs.
+var monitorSettingColumns = []string{
+	"name", "interval", "timeout", "confirm_after", "retry_interval",
+	"method", "headers", "body", "accepted_status_codes",
+	"keyword", "invert_keyword", "body_regex", "json_assertions",
+	"expected_banner",
+	"dns_record_type", "dns_resolver", "dns_expected",
+	"grace_period", "parent_id",
+}
+
+func monitorSettingArgs(m models.Monitor) []any {
+	return []any{
+		m.Name, m.Interval, m.Timeout, m.ConfirmAfter, m.RetryInterval,
+		m.Method, jsonText(m.Headers), m.Body, m.AcceptedStatus,
+		m.Keyword, m.InvertKeyword, m.BodyRegex, jsonText(m.JSONAssertions),
+		m.ExpectedBanner,
+		m.DNSRecordType, m.DNSResolver, m.DNSExpected,
+		m.GracePeriod, m.ParentID,
+	}
+}
+
+var monitorColumns = "id, type, url, push_token, last_push_at, last_checked_at, " +
+	"status, status_since, consecutive_failures, flapping, active, " + strings.Join(monitorSettingColumns, ", ")
+
 func CreateMonitor(ctx context.Context, db *sql.DB, monitor models.Monitor) (int64, error) {
-	query := "INSERT INTO monitors (name, url, interval) VALUES (?, ?, ?)"
+	columns := append([]string{"type", "url", "push_token", "last_push_at"}, monitorSettingColumns...)
+	args := append([]any{monitor.Type, monitor.URL, monitor.PushToken, monitor.LastPushAt}, monitorSettingArgs(monitor)...)
+	query := fmt.Sprintf("INSERT INTO monitors (%s) VALUES (%s)",
+		strings.Join(columns, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "),
+	)
 
-	result, err := db.ExecContext(ctx, query, monitor.Name, monitor.URL, monitor.Interval)
+	result, err := db.ExecContext(ctx, query, args...)
 	if err != nil {
 		return 0, err
 	}
@@ -19,16 +52,16 @@ func CreateMonitor(ctx context.Context, db *sql.DB, monitor models.Monitor) (int
 }
 
 func UpdateMonitor(ctx context.Context, db *sql.DB, monitor models.Monitor) error {
-	if monitor.URL == "" {
-		_, err := db.ExecContext(ctx, "UPDATE monitors SET name = ?, interval = ? WHERE id = ?", monitor.Name, monitor.Interval, monitor.ID)
-		return err
-	}
-	_, err := db.ExecContext(ctx, "UPDATE monitors SET name = ?, url = ?, interval = ? WHERE id = ?", monitor.Name, monitor.URL, monitor.Interval, monitor.ID)
+	columns := append([]string{"type", "url", "push_token"}, monitorSettingColumns...)
+	args := append([]any{monitor.Type, monitor.URL, monitor.PushToken}, monitorSettingArgs(monitor)...)
+
+	query := fmt.Sprintf("UPDATE monitors SET %s = ? WHERE id = ?", strings.Join(columns, " = ?, "))
+	_, err := db.ExecContext(ctx, query, append(args, monitor.ID)...)
 	return err
 }
 
 func GetMonitors(ctx context.Context, db *sql.DB) ([]models.Monitor, error) {
-	query := "SELECT id, name, url, interval, last_checked_at FROM monitors"
+	query := "SELECT " + monitorColumns + " FROM monitors"
 
 	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
@@ -38,26 +71,148 @@ func GetMonitors(ctx context.Context, db *sql.DB) ([]models.Monitor, error) {
 
 	var monitors []models.Monitor
 	for rows.Next() {
-		var m models.Monitor
-		var lastChecked sql.NullTime
-		if err := rows.Scan(&m.ID, &m.Name, &m.URL, &m.Interval, &lastChecked); err != nil {
+		m, err := scanMonitor(rows)
+		if err != nil {
 			return nil, err
 		}
-		if lastChecked.Valid {
-			m.LastCheckedAt = &lastChecked.Time
-		}
 		monitors = append(monitors, m)
 	}
 
 	return monitors, nil
 }
 
+// GetMonitor returns the monitor with the given ID, or nil if it does not
+// exist.
+func GetMonitor(ctx context.Context, db *sql.DB, id int64) (*models.Monitor, error) {
+	row := db.QueryRowContext(ctx, "SELECT "+monitorColumns+" FROM monitors WHERE id = ?", id)
+	m, err := scanMonitor(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// GetMonitorByPushToken returns the push monitor owning the token, or nil if
+// no monitor matches.
+func GetMonitorByPushToken(ctx context.Context, db *sql.DB, token string) (*models.Monitor, error) {
+	row := db.QueryRowContext(ctx,
+		"SELECT "+monitorColumns+" FROM monitors WHERE type = ? AND push_token = ?",
+		models.MonitorTypePush, token,
+	)
+	m, err := scanMonitor(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// GetDependentMonitors returns the monitors whose parent is parentID.
+func GetDependentMonitors(ctx context.Context, db *sql.DB, parentID int64) ([]models.Monitor, error) {
+	rows, err := db.QueryContext(ctx, "SELECT "+monitorColumns+" FROM monitors WHERE parent_id = ? ORDER BY name", parentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var monitors []models.Monitor
+	for rows.Next() {
+		m, err := scanMonitor(rows)
+		if err != nil {
+			return nil, err
+		}
+		monitors = append(monitors, m)
+	}
+	return monitors, rows.Err()
+}
+
+func RecordHeartbeat(ctx context.Context, db *sql.DB, monitorID int64) error {
+	_, err := db.ExecContext(ctx, "UPDATE monitors SET last_push_at = ? WHERE id = ?", time.Now(), monitorID)
+	return err
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanMonitor(row scanner) (models.Monitor, error) {
+	var m models.Monitor
+	var lastPush, lastChecked, statusSince sql.NullTime
+	var headers, jsonAssertions string
+	err := row.Scan(
+		&m.ID, &m.Type, &m.URL, &m.PushToken, &lastPush, &lastChecked,
+		&m.Status, &statusSince, &m.ConsecutiveFailures, &m.Flapping, &m.Active,
+		&m.Name, &m.Interval, &m.Timeout, &m.ConfirmAfter, &m.RetryInterval,
+		&m.Method, &headers, &m.Body, &m.AcceptedStatus,
+		&m.Keyword, &m.InvertKeyword, &m.BodyRegex, &jsonAssertions,
+		&m.ExpectedBanner,
+		&m.DNSRecordType, &m.DNSResolver, &m.DNSExpected,
+		&m.GracePeriod, &m.ParentID,
+	)
+	if err != nil {
+		return m, err
+	}
+	if lastPush.Valid {
+		m.LastPushAt = &lastPush.Time
+	}
+	if lastChecked.Valid {
+		m.LastCheckedAt = &lastChecked.Time
+	}
+	if statusSince.Valid {
+		m.StatusSince = &statusSince.Time
+	}
+	if err := json.Unmarshal([]byte(headers), &m.Headers); err != nil {
+		return m, err
+	}
+	if err := json.Unmarshal([]byte(jsonAssertions), &m.JSONAssertions); err != nil {
+		return m, err
+	}
+	return m, nil
+}
+
+// jsonText encodes list and map columns, which are stored as JSON text.
+func jsonText(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
 func UpdateLastChecked(ctx context.Context, db *sql.DB, monitorID int64) error {
 	query := "UPDATE monitors SET last_checked_at = ? WHERE id = ?"
 	_, err := db.ExecContext(ctx, query, time.Now(), monitorID)
 	return err
 }
 
+// UpdateMonitorStatus records the worker's view of a monitor so transitions
+// are still detected after a restart.
+func UpdateMonitorStatus(ctx context.Context, db *sql.DB, monitorID int64, status string, since time.Time, failures int, flapping bool) error {
+	query := "UPDATE monitors SET status = ?, status_since = ?, consecutive_failures = ?, flapping = ? WHERE id = ?"
+	var sinceArg any
+	if !since.IsZero() {
+		sinceArg = since
+	}
+	_, err := db.ExecContext(ctx, query, status, sinceArg, failures, flapping, monitorID)
+	return err
+}
+
+// SetMonitorActive pauses or resumes a monitor. It reports false if the
+// monitor does not exist.
+func SetMonitorActive(ctx context.Context, db *sql.DB, monitorID int64, active bool) (bool, error) {
+	result, err := db.ExecContext(ctx, "UPDATE monitors SET active = ? WHERE id = ?", active, monitorID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
 func DeleteMonitor(ctx context.Context, db *sql.DB, id int) error {
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
@@ -68,6 +223,9 @@ func DeleteMonitor(ctx context.Context, db *sql.DB, id int) error {
 	if _, err := tx.ExecContext(ctx, "DELETE FROM checks WHERE monitor_id = ?", id); err != nil {
 		return err
 	}
+	if _, err := tx.ExecContext(ctx, "UPDATE monitors SET parent_id = 0 WHERE parent_id = ?", id); err != nil {
+		return err
+	}
 	if _, err := tx.ExecContext(ctx, "DELETE FROM monitors WHERE id = ?", id); err != nil {
 		return err
 	}
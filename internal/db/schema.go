@@ -19,6 +19,31 @@ CREATE TABLE IF NOT EXISTS monitors (
     name TEXT NOT NULL,
     url TEXT NOT NULL,
     interval INTEGER DEFAULT 60,
+    timeout INTEGER NOT NULL DEFAULT 0,
+    confirm_after INTEGER NOT NULL DEFAULT 0,
+    retry_interval INTEGER NOT NULL DEFAULT 0,
+    type TEXT NOT NULL DEFAULT 'http',
+    method TEXT NOT NULL DEFAULT '',
+    headers TEXT NOT NULL DEFAULT '{}',
+    body TEXT NOT NULL DEFAULT '',
+    accepted_status_codes TEXT NOT NULL DEFAULT '',
+    keyword TEXT NOT NULL DEFAULT '',
+    invert_keyword BOOLEAN NOT NULL DEFAULT 0,
+    body_regex TEXT NOT NULL DEFAULT '',
+    json_assertions TEXT NOT NULL DEFAULT '[]',
+    expected_banner TEXT NOT NULL DEFAULT '',
+    dns_record_type TEXT NOT NULL DEFAULT '',
+    dns_resolver TEXT NOT NULL DEFAULT '',
+    dns_expected TEXT NOT NULL DEFAULT '',
+    push_token TEXT NOT NULL DEFAULT '',
+    grace_period INTEGER NOT NULL DEFAULT 0,
+    parent_id INTEGER NOT NULL DEFAULT 0,
+    last_push_at TIMESTAMP,
+    status TEXT NOT NULL DEFAULT '',
+    status_since TIMESTAMP,
+    consecutive_failures INTEGER NOT NULL DEFAULT 0,
+    flapping BOOLEAN NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT 1,
     last_checked_at TIMESTAMP,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
@@ -29,6 +54,15 @@ CREATE TABLE IF NOT EXISTS checks (
     status_code INTEGER,
     latency INTEGER,
     is_up BOOLEAN,
+    assertion TEXT NOT NULL DEFAULT '',
+    error_category TEXT NOT NULL DEFAULT '',
+    error_message TEXT NOT NULL DEFAULT '',
+    dns_ms INTEGER NOT NULL DEFAULT 0,
+    connect_ms INTEGER NOT NULL DEFAULT 0,
+    tls_ms INTEGER NOT NULL DEFAULT 0,
+    ttfb_ms INTEGER NOT NULL DEFAULT 0,
+    transfer_ms INTEGER NOT NULL DEFAULT 0,
+    maintenance BOOLEAN NOT NULL DEFAULT 0,
     checked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
     FOREIGN KEY (monitor_id) REFERENCES monitors (id)
 );
@@ -40,6 +74,17 @@ CREATE TABLE IF NOT EXISTS daily_stats (
     up_count INTEGER DEFAULT 0,
     total_count INTEGER DEFAULT 0,
     total_latency INTEGER DEFAULT 0,
+    total_dns INTEGER NOT NULL DEFAULT 0,
+    total_connect INTEGER NOT NULL DEFAULT 0,
+    total_tls INTEGER NOT NULL DEFAULT 0,
+    total_ttfb INTEGER NOT NULL DEFAULT 0,
+    total_transfer INTEGER NOT NULL DEFAULT 0,
+    dns_count INTEGER NOT NULL DEFAULT 0,
+    connect_count INTEGER NOT NULL DEFAULT 0,
+    tls_count INTEGER NOT NULL DEFAULT 0,
+    ttfb_count INTEGER NOT NULL DEFAULT 0,
+    transfer_count INTEGER NOT NULL DEFAULT 0,
+    maintenance_count INTEGER NOT NULL DEFAULT 0,
     PRIMARY KEY (monitor_id, date),
     FOREIGN KEY (monitor_id) REFERENCES monitors (id) ON DELETE CASCADE
     );
@@ -56,17 +101,144 @@ CREATE TABLE IF NOT EXISTS daily_stats (
 CREATE TABLE IF NOT EXISTS webhooks (
     id INTEGER PRIMARY KEY AUTOINCREMENT,
     name TEXT NOT NULL,
+    type TEXT NOT NULL DEFAULT 'discord',
     url TEXT NOT NULL,
+    settings TEXT NOT NULL DEFAULT '{}',
+    headers TEXT NOT NULL DEFAULT '{}',
     enabled INTEGER NOT NULL DEFAULT 1,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+CREATE TABLE IF NOT EXISTS deliveries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    webhook_id INTEGER NOT NULL,
+    event TEXT NOT NULL,
+    monitor_id INTEGER NOT NULL DEFAULT 0,
+    payload TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP,
+    last_status_code INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    FOREIGN KEY (webhook_id) REFERENCES webhooks(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS delivery_attempts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    delivery_id INTEGER NOT NULL,
+    attempted_at TIMESTAMP NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    error TEXT NOT NULL DEFAULT '',
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    FOREIGN KEY (delivery_id) REFERENCES deliveries(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS certificates (
+    monitor_id INTEGER PRIMARY KEY,
+    subject TEXT NOT NULL,
+    issuer TEXT NOT NULL,
+    sans TEXT NOT NULL DEFAULT '',
+    not_before TIMESTAMP,
+    not_after TIMESTAMP,
+    last_warned_days INTEGER NOT NULL DEFAULT 0,
+    checked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (monitor_id) REFERENCES monitors (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS maintenance_windows (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    title TEXT NOT NULL,
+    timezone TEXT NOT NULL DEFAULT 'UTC',
+    starts_at TIMESTAMP,
+    ends_at TIMESTAMP,
+    weekdays TEXT NOT NULL DEFAULT '[]',
+    start_time TEXT NOT NULL DEFAULT '',
+    duration INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS maintenance_monitors (
+    window_id INTEGER NOT NULL,
+    monitor_id INTEGER NOT NULL,
+    PRIMARY KEY (window_id, monitor_id),
+    FOREIGN KEY (window_id) REFERENCES maintenance_windows (id) ON DELETE CASCADE,
+    FOREIGN KEY (monitor_id) REFERENCES monitors (id) ON DELETE CASCADE
+);
+
 CREATE INDEX IF NOT EXISTS idx_checks_monitor_id ON checks(monitor_id);
 CREATE INDEX IF NOT EXISTS idx_checks_checked_at ON checks(checked_at);
 CREATE INDEX IF NOT EXISTS idx_checks_monitor_checked ON checks(monitor_id, checked_at DESC);
 CREATE INDEX IF NOT EXISTS idx_daily_stats_date ON daily_stats(date);
 CREATE INDEX IF NOT EXISTS idx_incidents_created_at ON incidents(created_at DESC);
 CREATE INDEX IF NOT EXISTS idx_webhooks_enabled ON webhooks(enabled);
+CREATE INDEX IF NOT EXISTS idx_maintenance_monitors_monitor ON maintenance_monitors(monitor_id);
+CREATE INDEX IF NOT EXISTS idx_deliveries_due ON deliveries(status, next_attempt_at);
+CREATE INDEX IF NOT EXISTS idx_deliveries_webhook ON deliveries(webhook_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_delivery_attempts_delivery ON delivery_attempts(delivery_id);
+`
+
+// migrations adds columns introduced after a table was first created.
+// CREATE TABLE IF NOT EXISTS leaves existing databases untouched, so every
+// new column must also be listed here.
+var migrations = []struct {
+	table, column, definition string
+}{
+	{"monitors", "type", "TEXT NOT NULL DEFAULT 'http'"},
+	{"monitors", "expected_banner", "TEXT NOT NULL DEFAULT ''"},
+	{"monitors", "dns_record_type", "TEXT NOT NULL DEFAULT ''"},
+	{"monitors", "dns_resolver", "TEXT NOT NULL DEFAULT ''"},
+	{"monitors", "dns_expected", "TEXT NOT NULL DEFAULT ''"},
+	{"monitors", "keyword", "TEXT NOT NULL DEFAULT ''"},
+	{"monitors", "invert_keyword", "BOOLEAN NOT NULL DEFAULT 0"},
+	{"monitors", "body_regex", "TEXT NOT NULL DEFAULT ''"},
+	{"monitors", "json_assertions", "TEXT NOT NULL DEFAULT '[]'"},
+	{"checks", "assertion", "TEXT NOT NULL DEFAULT ''"},
+	{"monitors", "method", "TEXT NOT NULL DEFAULT ''"},
+	{"monitors", "headers", "TEXT NOT NULL DEFAULT '{}'"},
+	{"monitors", "body", "TEXT NOT NULL DEFAULT ''"},
+	{"monitors", "accepted_status_codes", "TEXT NOT NULL DEFAULT ''"},
+	{"monitors", "timeout", "INTEGER NOT NULL DEFAULT 0"},
+	{"monitors", "confirm_after", "INTEGER NOT NULL DEFAULT 0"},
+	{"monitors", "retry_interval", "INTEGER NOT NULL DEFAULT 0"},
+	{"monitors", "push_token", "TEXT NOT NULL DEFAULT ''"},
+	{"monitors", "grace_period", "INTEGER NOT NULL DEFAULT 0"},
+	{"monitors", "last_push_at", "TIMESTAMP"},
+	{"checks", "error_category", "TEXT NOT NULL DEFAULT ''"},
+	{"checks", "error_message", "TEXT NOT NULL DEFAULT ''"},
+	{"checks", "dns_ms", "INTEGER NOT NULL DEFAULT 0"},
+	{"checks", "connect_ms", "INTEGER NOT NULL DEFAULT 0"},
+	{"checks", "tls_ms", "INTEGER NOT NULL DEFAULT 0"},
+	{"checks", "ttfb_ms", "INTEGER NOT NULL DEFAULT 0"},
+	{"checks", "transfer_ms", "INTEGER NOT NULL DEFAULT 0"},
+	{"daily_stats", "total_dns", "INTEGER NOT NULL DEFAULT 0"},
+	{"daily_stats", "total_connect", "INTEGER NOT NULL DEFAULT 0"},
+	{"daily_stats", "total_tls", "INTEGER NOT NULL DEFAULT 0"},
+	{"daily_stats", "total_ttfb", "INTEGER NOT NULL DEFAULT 0"},
+	{"daily_stats", "total_transfer", "INTEGER NOT NULL DEFAULT 0"},
+	{"monitors", "status", "TEXT NOT NULL DEFAULT ''"},
+	{"monitors", "status_since", "TIMESTAMP"},
+	{"monitors", "consecutive_failures", "INTEGER NOT NULL DEFAULT 0"},
+	{"monitors", "active", "BOOLEAN NOT NULL DEFAULT 1"},
+	{"checks", "maintenance", "BOOLEAN NOT NULL DEFAULT 0"},
+	{"daily_stats", "maintenance_count", "INTEGER NOT NULL DEFAULT 0"},
+	{"monitors", "parent_id", "INTEGER NOT NULL DEFAULT 0"},
+	{"monitors", "flapping", "BOOLEAN NOT NULL DEFAULT 0"},
+	{"webhooks", "type", "TEXT NOT NULL DEFAULT 'discord'"},
+	{"webhooks", "settings", "TEXT NOT NULL DEFAULT '{}'"},
+	{"webhooks", "headers", "TEXT NOT NULL DEFAULT '{}'"},
+	{"daily_stats", "dns_count", "INTEGER NOT NULL DEFAULT 0"},
+	{"daily_stats", "connect_count", "INTEGER NOT NULL DEFAULT 0"},
+	{"daily_stats", "tls_count", "INTEGER NOT NULL DEFAULT 0"},
+	{"daily_stats", "ttfb_count", "INTEGER NOT NULL DEFAULT 0"},
+	{"daily_stats", "transfer_count", "INTEGER NOT NULL DEFAULT 0"},
+}
+
+// migratedIndexes reference columns added by migrations, so they can only be
+// created once the migrations have run.
+const migratedIndexes = `
+CREATE UNIQUE INDEX IF NOT EXISTS idx_monitors_push_token ON monitors(push_token) WHERE push_token != '';
+CREATE INDEX IF NOT EXISTS idx_monitors_parent_id ON monitors(parent_id) WHERE parent_id != 0;
 `
 
 func Initialize(db *sql.DB) error {
@@ -75,7 +247,40 @@ func Initialize(db *sql.DB) error {
 			return fmt.Errorf("failed to set %s: %w", p, err)
 		}
 	}
-	_, err := db.Exec(schema)
+	if _, err := db.Exec(schema); err != nil {
+		return err
+	}
+	for _, m := range migrations {
+		if err := addColumn(db, m.table, m.column, m.definition); err != nil {
+			return fmt.Errorf("failed to migrate %s.%s: %w", m.table, m.column, err)
+		}
+	}
+	_, err := db.Exec(migratedIndexes)
+	return err
+}
+
+func addColumn(db *sql.DB, table, column, definition string) error {
+	rows, err := db.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+
+	_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
 	return err
 }
 
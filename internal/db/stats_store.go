@@ -8,7 +8,9 @@ import (
 
 func GetMonitorHistory(ctx context.Context, db *sql.DB, monitorID int64) ([]models.DailyStat, error) {
 	query := `
-		SELECT date, up_count, total_count, total_latency
+		SELECT date, up_count, total_count, total_latency,
+			total_dns, total_connect, total_tls, total_ttfb, total_transfer,
+			dns_count, connect_count, tls_count, ttfb_count, transfer_count, maintenance_count
 		FROM daily_stats
 		WHERE monitor_id = ?
 		ORDER BY date DESC
@@ -23,15 +25,14 @@ func GetMonitorHistory(ctx context.Context, db *sql.DB, monitorID int64) ([]mode
 	var stats []models.DailyStat
 	for rows.Next() {
 		var date string
-		var up, total, lat int64
-		if err := rows.Scan(&date, &up, &total, &lat); err != nil {
+		var up, total, lat, maintenance int64
+		var t, n models.Timings // phase sums, and how many checks had each phase
+		if err := rows.Scan(&date, &up, &total, &lat, &t.DNS, &t.Connect, &t.TLS, &t.TTFB, &t.Transfer,
+			&n.DNS, &n.Connect, &n.TLS, &n.TTFB, &n.Transfer, &maintenance); err != nil {
 			return nil, err
 		}
 
-		pct := 0.0
-		if total > 0 {
-			pct = (float64(up) / float64(total)) * 100
-		}
+		pct := uptimePct(up, total, maintenance)
 
 		avg := int64(0)
 		if total > 0 {
@@ -43,6 +44,9 @@ func GetMonitorHistory(ctx context.Context, db *sql.DB, monitorID int64) ([]mode
 			Date:       date,
 			UptimePct:  pct,
 			AvgLatency: avg,
+			AvgTimings: averageTimings(t, n, total),
+
+			MaintenanceCount: maintenance,
 		})
 	}
 	return stats, nil
@@ -50,7 +54,9 @@ func GetMonitorHistory(ctx context.Context, db *sql.DB, monitorID int64) ([]mode
 
 func GetAllMonitorHistory(ctx context.Context, db *sql.DB) (map[int64][]models.DailyStat, error) {
 	query := `
-		SELECT monitor_id, date, up_count, total_count, total_latency
+		SELECT monitor_id, date, up_count, total_count, total_latency,
+			total_dns, total_connect, total_tls, total_ttfb, total_transfer,
+			dns_count, connect_count, tls_count, ttfb_count, transfer_count, maintenance_count
 		FROM daily_stats
 		WHERE date >= date('now', '-30 days')
 		ORDER BY monitor_id, date DESC
@@ -65,15 +71,14 @@ func GetAllMonitorHistory(ctx context.Context, db *sql.DB) (map[int64][]models.D
 	for rows.Next() {
 		var monitorID int64
 		var date string
-		var up, total, lat int64
-		if err := rows.Scan(&monitorID, &date, &up, &total, &lat); err != nil {
+		var up, total, lat, maintenance int64
+		var t, n models.Timings // phase sums, and how many checks had each phase
+		if err := rows.Scan(&monitorID, &date, &up, &total, &lat, &t.DNS, &t.Connect, &t.TLS, &t.TTFB, &t.Transfer,
+			&n.DNS, &n.Connect, &n.TLS, &n.TTFB, &n.Transfer, &maintenance); err != nil {
 			return nil, err
 		}
 
-		pct := 0.0
-		if total > 0 {
-			pct = (float64(up) / float64(total)) * 100
-		}
+		pct := uptimePct(up, total, maintenance)
 
 		avg := int64(0)
 		if total > 0 {
@@ -85,7 +90,45 @@ func GetAllMonitorHistory(ctx context.Context, db *sql.DB) (map[int64][]models.D
 			Date:       date,
 			UptimePct:  pct,
 			AvgLatency: avg,
+			AvgTimings: averageTimings(t, n, total),
+
+			MaintenanceCount: maintenance,
 		})
 	}
 	return grouped, nil
 }
+
+// uptimePct leaves checks taken during maintenance out of the ratio. A day
+// spent entirely in maintenance counts as fully up.
+func uptimePct(up, total, maintenance int64) float64 {
+	counted := total - maintenance
+	if counted <= 0 {
+		if total > 0 {
+			return 100
+		}
+		return 0
+	}
+	return (float64(up) / float64(counted)) * 100
+}
+
+// averageTimings divides each summed phase by the number of checks that
+// went through it. Days recorded before phases were counted have no counts
+// and fall back to the total number of checks.
+func averageTimings(sum, counts models.Timings, total int64) models.Timings {
+	avg := func(sum, count int64) int64 {
+		if count == 0 {
+			count = total
+		}
+		if count == 0 {
+			return 0
+		}
+		return sum / count
+	}
+	return models.Timings{
+		DNS:      avg(sum.DNS, counts.DNS),
+		Connect:  avg(sum.Connect, counts.Connect),
+		TLS:      avg(sum.TLS, counts.TLS),
+		TTFB:     avg(sum.TTFB, counts.TTFB),
+		Transfer: avg(sum.Transfer, counts.Transfer),
+	}
+}
@@ -3,13 +3,21 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"go-sentinel/internal/models"
 )
 
+const webhookColumns = "id, name, type, url, settings, headers, enabled"
+
+// CreateWebhook stores a new webhook. One without a type is a Discord
+// webhook, as it was before channel types existed.
 func CreateWebhook(ctx context.Context, db *sql.DB, wh models.Webhook) (int64, error) {
+	if wh.Type == "" {
+		wh.Type = models.WebhookTypeDiscord
+	}
 	result, err := db.ExecContext(ctx,
-		"INSERT INTO webhooks (name, url, enabled) VALUES (?, ?, ?)",
-		wh.Name, wh.URL, wh.Enabled,
+		"INSERT INTO webhooks (name, type, url, settings, headers, enabled) VALUES (?, ?, ?, ?, ?, ?)",
+		wh.Name, wh.Type, wh.URL, jsonText(wh.Settings), jsonText(wh.Headers), wh.Enabled,
 	)
 	if err != nil {
 		return 0, err
@@ -17,32 +25,29 @@ func CreateWebhook(ctx context.Context, db *sql.DB, wh models.Webhook) (int64, e
 	return result.LastInsertId()
 }
 
-func GetWebhooks(ctx context.Context, db *sql.DB) ([]models.Webhook, error) {
-	rows, err := db.QueryContext(ctx,
-		"SELECT id, name, url, enabled FROM webhooks ORDER BY id ASC",
-	)
+// GetWebhook returns the webhook with the given ID, or nil if there is none.
+func GetWebhook(ctx context.Context, db *sql.DB, id int64) (*models.Webhook, error) {
+	row := db.QueryRowContext(ctx, "SELECT "+webhookColumns+" FROM webhooks WHERE id = ?", id)
+	wh, err := scanWebhook(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
+	return &wh, nil
+}
 
-	webhooks := []models.Webhook{}
-	for rows.Next() {
-		var wh models.Webhook
-		var enabled int
-		if err := rows.Scan(&wh.ID, &wh.Name, &wh.URL, &enabled); err != nil {
-			return nil, err
-		}
-		wh.Enabled = enabled == 1
-		webhooks = append(webhooks, wh)
-	}
-	return webhooks, nil
+func GetWebhooks(ctx context.Context, db *sql.DB) ([]models.Webhook, error) {
+	return queryWebhooks(ctx, db, "SELECT "+webhookColumns+" FROM webhooks ORDER BY id ASC")
 }
 
 func GetEnabledWebhooks(ctx context.Context, db *sql.DB) ([]models.Webhook, error) {
-	rows, err := db.QueryContext(ctx,
-		"SELECT id, name, url, enabled FROM webhooks WHERE enabled = 1 ORDER BY id ASC",
-	)
+	return queryWebhooks(ctx, db, "SELECT "+webhookColumns+" FROM webhooks WHERE enabled = 1 ORDER BY id ASC")
+}
+
+func queryWebhooks(ctx context.Context, db *sql.DB, query string) ([]models.Webhook, error) {
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -50,25 +55,40 @@ func GetEnabledWebhooks(ctx context.Context, db *sql.DB) ([]models.Webhook, erro
 
 	webhooks := []models.Webhook{}
 	for rows.Next() {
-		var wh models.Webhook
-		var enabled int
-		if err := rows.Scan(&wh.ID, &wh.Name, &wh.URL, &enabled); err != nil {
+		wh, err := scanWebhook(rows)
+		if err != nil {
 			return nil, err
 		}
-		wh.Enabled = enabled == 1
 		webhooks = append(webhooks, wh)
 	}
-	return webhooks, nil
+	return webhooks, rows.Err()
+}
+
+func scanWebhook(row scanner) (models.Webhook, error) {
+	var wh models.Webhook
+	var settings, headers string
+	var enabled int
+	if err := row.Scan(&wh.ID, &wh.Name, &wh.Type, &wh.URL, &settings, &headers, &enabled); err != nil {
+		return wh, err
+	}
+	wh.Enabled = enabled == 1
+	if err := json.Unmarshal([]byte(settings), &wh.Settings); err != nil {
+		return wh, err
+	}
+	if err := json.Unmarshal([]byte(headers), &wh.Headers); err != nil {
+		return wh, err
+	}
+	return wh, nil
 }
 
-func UpdateWebhook(ctx context.Context, db *sql.DB, id int64, name, url string, enabled bool) error {
+func UpdateWebhook(ctx context.Context, db *sql.DB, wh models.Webhook) error {
 	enabledInt := 0
-	if enabled {
+	if wh.Enabled {
 		enabledInt = 1
 	}
 	_, err := db.ExecContext(ctx,
-		"UPDATE webhooks SET name = ?, url = ?, enabled = ? WHERE id = ?",
-		name, url, enabledInt, id,
+		"UPDATE webhooks SET name = ?, type = ?, url = ?, settings = ?, headers = ?, enabled = ? WHERE id = ?",
+		wh.Name, wh.Type, wh.URL, jsonText(wh.Settings), jsonText(wh.Headers), enabledInt, wh.ID,
 	)
 	return err
 }
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+type Certificate struct {
+	MonitorID int64     `json:"monitor_id"`
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	SANs      []string  `json:"sans"`
+	NotBefore time.Time `json:"not_before"`
+	NotAfter  time.Time `json:"not_after"` // earliest expiry across the presented chain
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// DaysLeft returns the number of whole days until the certificate expires,
+// negative once it has expired.
+func (c *Certificate) DaysLeft(now time.Time) int {
+	return int(math.Floor(c.NotAfter.Sub(now).Hours() / 24))
+}
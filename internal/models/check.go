@@ -4,11 +4,30 @@ import (
 	"time"
 )
 
+// Error categories describing why a check failed.
+const (
+	ErrorDNS        = "dns"
+	ErrorTimeout    = "timeout"
+	ErrorRefused    = "connection_refused"
+	ErrorConnection = "connection"
+	ErrorTLS        = "tls"
+	ErrorBlocked    = "blocked"
+	ErrorHTTPStatus = "http_status"
+	ErrorAssertion  = "assertion"
+	ErrorHeartbeat  = "missed_heartbeat"
+	ErrorDependency = "dependency_down"
+)
+
 type Check struct {
-	ID         int64     `json:"-"`
-	MonitorID  int64     `json:"monitor_id"`
-	StatusCode int       `json:"-"`
-	Latency    int64     `json:"latency"`
-	IsUp       bool      `json:"is_up"`
-	CheckedAt  time.Time `json:"checked_at"`
+	ID            int64     `json:"-"`
+	MonitorID     int64     `json:"monitor_id"`
+	StatusCode    int       `json:"-"`
+	Latency       int64     `json:"latency"`
+	Timings       Timings   `json:"timings"`
+	IsUp          bool      `json:"is_up"`
+	Assertion     string    `json:"assertion,omitempty"` // first failed body assertion, if any
+	ErrorCategory string    `json:"error_category,omitempty"`
+	ErrorMessage  string    `json:"error_message,omitempty"`
+	Maintenance   bool      `json:"maintenance,omitempty"` // taken during a maintenance window
+	CheckedAt     time.Time `json:"checked_at"`
 }
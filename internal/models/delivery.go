@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Delivery states. A pending delivery is retried until it is delivered or
+// runs out of attempts, or until a newer event about the same monitor is
+// queued for the channel.
+const (
+	DeliveryPending    = "pending"
+	DeliveryDelivered  = "delivered"
+	DeliveryFailed     = "failed"
+	DeliverySuperseded = "superseded"
+)
+
+// Delivery is one notification queued for one channel.
+type Delivery struct {
+	ID             int64             `json:"id"`
+	WebhookID      int64             `json:"webhook_id"`
+	Event          string            `json:"event"`
+	MonitorID      int64             `json:"monitor_id"`
+	Status         string            `json:"status"`
+	AttemptCount   int               `json:"attempt_count"`
+	NextAttemptAt  *time.Time        `json:"next_attempt_at,omitempty"` // while pending
+	LastStatusCode int               `json:"last_status_code,omitempty"`
+	LastError      string            `json:"last_error,omitempty"`
+	CreatedAt      time.Time         `json:"created_at"`
+	Attempts       []DeliveryAttempt `json:"attempts"`
+	Payload        string            `json:"-"` // the encoded event
+}
+
+// DeliveryAttempt records one try at sending a delivery. StatusCode is the
+// HTTP status, or 0 when no response was received.
+type DeliveryAttempt struct {
+	AttemptedAt time.Time `json:"attempted_at"`
+	StatusCode  int       `json:"status_code,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	Duration    int64     `json:"duration_ms"`
+}
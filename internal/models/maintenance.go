@@ -0,0 +1,113 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// MaintenanceWindow is a period during which linked monitors are still
+// checked but their results neither alert nor count against uptime.
+//
+// One-off windows set StartsAt and EndsAt. Recurring windows repeat every
+// week on Weekdays (0 = Sunday), starting at StartTime ("15:04") in
+// Timezone and lasting Duration minutes.
+type MaintenanceWindow struct {
+	ID         int64      `json:"id"`
+	Title      string     `json:"title"`
+	Timezone   string     `json:"timezone"`
+	StartsAt   *time.Time `json:"starts_at,omitempty"`
+	EndsAt     *time.Time `json:"ends_at,omitempty"`
+	Weekdays   []int      `json:"weekdays,omitempty"`
+	StartTime  string     `json:"start_time,omitempty"`
+	Duration   int        `json:"duration,omitempty"`
+	MonitorIDs []int64    `json:"monitor_ids"`
+}
+
+func (mw *MaintenanceWindow) Validate() error {
+	if len(mw.Title) < 1 || len(mw.Title) > 200 {
+		return errors.New("title must be between 1-200 characters")
+	}
+
+	if mw.Timezone == "" {
+		mw.Timezone = "UTC"
+	}
+	if _, err := time.LoadLocation(mw.Timezone); err != nil {
+		return errors.New("timezone must be an IANA time zone such as Europe/Berlin")
+	}
+
+	if len(mw.MonitorIDs) == 0 {
+		return errors.New("monitor_ids must list at least one monitor")
+	}
+
+	recurring := len(mw.Weekdays) > 0 || mw.StartTime != "" || mw.Duration != 0
+	if recurring {
+		if mw.StartsAt != nil || mw.EndsAt != nil {
+			return errors.New("a window is either one-off (starts_at, ends_at) or weekly (weekdays, start_time, duration), not both")
+		}
+		if len(mw.Weekdays) == 0 {
+			return errors.New("weekdays must list at least one day (0 = Sunday)")
+		}
+		for _, d := range mw.Weekdays {
+			if d < 0 || d > 6 {
+				return errors.New("weekdays must be between 0 (Sunday) and 6 (Saturday)")
+			}
+		}
+		if _, err := time.Parse("15:04", mw.StartTime); err != nil {
+			return errors.New("start_time must be in HH:MM format")
+		}
+		if mw.Duration < 1 || mw.Duration > 7*24*60 {
+			return errors.New("duration must be between 1-10080 minutes")
+		}
+		return nil
+	}
+
+	if mw.StartsAt == nil || mw.EndsAt == nil {
+		return errors.New("one-off windows need starts_at and ends_at")
+	}
+	if !mw.EndsAt.After(*mw.StartsAt) {
+		return errors.New("ends_at must be after starts_at")
+	}
+	return nil
+}
+
+// ActiveAt reports whether the window covers the given instant.
+func (mw *MaintenanceWindow) ActiveAt(now time.Time) bool {
+	if len(mw.Weekdays) == 0 {
+		return mw.StartsAt != nil && mw.EndsAt != nil &&
+			!now.Before(*mw.StartsAt) && now.Before(*mw.EndsAt)
+	}
+
+	loc, err := time.LoadLocation(mw.Timezone)
+	if err != nil {
+		return false
+	}
+	start, err := time.Parse("15:04", mw.StartTime)
+	if err != nil {
+		return false
+	}
+	length := time.Duration(mw.Duration) * time.Minute
+
+	// A window can last up to a week, so one that started on any of the
+	// last seven days may still be running.
+	local := now.In(loc)
+	for back := 0; back <= 7; back++ {
+		day := local.AddDate(0, 0, -back)
+		if !mw.onWeekday(day.Weekday()) {
+			continue
+		}
+		from := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+		if !now.Before(from) && now.Before(from.Add(length)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (mw *MaintenanceWindow) onWeekday(d time.Weekday) bool {
+	for _, w := range mw.Weekdays {
+		if time.Weekday(w) == d {
+			return true
+		}
+	}
+	return false
+}
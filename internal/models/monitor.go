@@ -2,41 +2,337 @@ package models
 
 import (
 	"errors"
+	"go-sentinel/internal/assertion"
+	"net"
 	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 )
 
+const (
+	DefaultTimeout = 5 * time.Second
+	MaxTimeout     = 60 * time.Second
+)
+
+// Status values reported by the worker. A monitor without a confirmed
+// result yet has an empty status.
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+const (
+	MonitorTypeHTTP = "http"
+	MonitorTypeTCP  = "tcp"
+	MonitorTypeDNS  = "dns"
+	MonitorTypePush = "push"
+)
+
+var httpMethods = map[string]bool{
+	"GET":     true,
+	"HEAD":    true,
+	"POST":    true,
+	"PUT":     true,
+	"PATCH":   true,
+	"DELETE":  true,
+	"OPTIONS": true,
+}
+
+var dnsRecordTypes = map[string]bool{
+	"A":     true,
+	"AAAA":  true,
+	"CNAME": true,
+	"MX":    true,
+	"TXT":   true,
+}
+
 type Monitor struct {
-	ID            int64      `json:"id"`
-	Name          string     `json:"name"`
-	URL           string     `json:"url"`
-	Interval      int        `json:"interval"`
-	LastCheckedAt *time.Time `json:"last_checked_at,omitempty"`
+	ID             int64             `json:"id"`
+	Name           string            `json:"name"`
+	Type           string            `json:"type"`
+	URL            string            `json:"url"`
+	Interval       int               `json:"interval"`
+	Timeout        int               `json:"timeout,omitempty"`        // seconds, 0 = DefaultTimeout
+	ConfirmAfter   int               `json:"confirm_after,omitempty"`  // consecutive failures before down, 0 = 1
+	RetryInterval  int               `json:"retry_interval,omitempty"` // seconds between checks while failing, 0 = Interval
+	Method         string            `json:"method,omitempty"`
+	Headers        map[string]string `json:"headers,omitempty"`
+	Body           string            `json:"body,omitempty"`
+	AcceptedStatus string            `json:"accepted_status_codes,omitempty"`
+	Keyword        string            `json:"keyword,omitempty"`
+	InvertKeyword  bool              `json:"invert_keyword,omitempty"`
+	BodyRegex      string            `json:"body_regex,omitempty"`
+	JSONAssertions []string          `json:"json_assertions,omitempty"`
+	ExpectedBanner string            `json:"expected_banner,omitempty"`
+	DNSRecordType  string            `json:"dns_record_type,omitempty"`
+	DNSResolver    string            `json:"dns_resolver,omitempty"`
+	DNSExpected    string            `json:"dns_expected,omitempty"`
+	PushToken      string            `json:"push_token,omitempty"`
+	GracePeriod    int               `json:"grace_period,omitempty"` // seconds a heartbeat may be late
+	ParentID       int64             `json:"parent_id,omitempty"`    // monitor this one depends on
+	LastPushAt     *time.Time        `json:"last_push_at,omitempty"`
+	LastCheckedAt  *time.Time        `json:"last_checked_at,omitempty"`
+
+	Active              bool       `json:"active"` // false while paused
+	Status              string     `json:"status"`
+	StatusSince         *time.Time `json:"status_since,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	Flapping            bool       `json:"flapping"` // changing state too often to alert on each change
 }
 
 func (m *Monitor) Validate() error {
 	if len(m.Name) < 1 || len(m.Name) > 200 {
 		return errors.New("name must be between 1-200 characters")
 	}
-	
+
 	if m.Interval < 10 || m.Interval > 86400 {
 		return errors.New("interval must be between 10-86400 seconds (10s to 24h)")
 	}
-	
+
+	if m.Timeout < 0 || time.Duration(m.Timeout)*time.Second > MaxTimeout {
+		return errors.New("timeout must be between 1-60 seconds")
+	}
+
+	if m.ConfirmAfter < 0 || m.ConfirmAfter > 10 {
+		return errors.New("confirm_after must be between 1-10 consecutive failures")
+	}
+
+	if m.RetryInterval != 0 && (m.RetryInterval < 10 || m.RetryInterval > m.Interval) {
+		return errors.New("retry_interval must be between 10 seconds and the monitor interval")
+	}
+
+	if m.ParentID < 0 || (m.ParentID != 0 && m.ParentID == m.ID) {
+		return errors.New("parent_id must refer to another monitor")
+	}
+
+	if m.Type == "" {
+		m.Type = MonitorTypeHTTP
+	}
+
+	switch m.Type {
+	case MonitorTypeHTTP:
+		return m.validateHTTP()
+	case MonitorTypeTCP:
+		return m.validateTCP()
+	case MonitorTypeDNS:
+		return m.validateDNS()
+	case MonitorTypePush:
+		return m.validatePush()
+	default:
+		return errors.New("type must be one of: http, tcp, dns, push")
+	}
+}
+
+func (m *Monitor) validateHTTP() error {
 	parsedURL, err := url.Parse(m.URL)
 	if err != nil {
 		return errors.New("invalid URL format")
 	}
-	
+
 	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
 		return errors.New("URL must use http or https scheme")
 	}
-	
+
 	if parsedURL.Host == "" {
 		return errors.New("URL must have a valid host")
 	}
-	
+
+	if m.ExpectedBanner != "" {
+		return errors.New("expected_banner is only supported for tcp monitors")
+	}
+
+	m.Method = strings.ToUpper(m.Method)
+	if m.Method != "" && !httpMethods[m.Method] {
+		return errors.New("method must be one of: GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS")
+	}
+
+	if m.Method == "HEAD" && m.HasBodyAssertions() {
+		return errors.New("body assertions cannot be used with the HEAD method")
+	}
+
+	if len(m.Headers) > 50 {
+		return errors.New("at most 50 headers are allowed")
+	}
+	for name, value := range m.Headers {
+		if name == "" || strings.ContainsAny(name, " :\r\n") || strings.ContainsAny(value, "\r\n") {
+			return errors.New("headers must have non-empty names without spaces, colons or line breaks")
+		}
+		if len(name)+len(value) > 8192 {
+			return errors.New("each header must be at most 8192 characters")
+		}
+	}
+
+	if len(m.Body) > 65536 {
+		return errors.New("body must be at most 65536 characters")
+	}
+
+	if _, err := parseStatusRanges(m.AcceptedStatus); err != nil {
+		return err
+	}
+
+	if len(m.Keyword) > 1024 {
+		return errors.New("keyword must be at most 1024 characters")
+	}
+
+	if len(m.BodyRegex) > 1024 {
+		return errors.New("body_regex must be at most 1024 characters")
+	}
+
+	if m.BodyRegex != "" {
+		if _, err := regexp.Compile(m.BodyRegex); err != nil {
+			return errors.New("body_regex is not a valid regular expression")
+		}
+	}
+
+	if len(m.JSONAssertions) > 20 {
+		return errors.New("at most 20 json_assertions are allowed")
+	}
+	for _, a := range m.JSONAssertions {
+		if len(a) > 512 {
+			return errors.New("each json assertion must be at most 512 characters")
+		}
+	}
+	if _, err := assertion.ParseAll(m.JSONAssertions); err != nil {
+		return err
+	}
+
 	return nil
 }
 
+func (m *Monitor) validatePush() error {
+	if m.URL != "" {
+		return errors.New("push monitors do not take a URL")
+	}
+
+	if m.GracePeriod < 0 || m.GracePeriod > 86400 {
+		return errors.New("grace_period must be between 0-86400 seconds")
+	}
+
+	return nil
+}
+
+// HeartbeatOverdue reports whether a push monitor has gone longer than its
+// interval plus grace period without a heartbeat.
+func (m *Monitor) HeartbeatOverdue(now time.Time) bool {
+	if m.LastPushAt == nil {
+		return true
+	}
+	return now.Sub(*m.LastPushAt) > time.Duration(m.Interval+m.GracePeriod)*time.Second
+}
+
+// TimeoutDuration returns how long a single check may take.
+func (m *Monitor) TimeoutDuration() time.Duration {
+	if m.Timeout <= 0 {
+		return DefaultTimeout
+	}
+	return time.Duration(m.Timeout) * time.Second
+}
+
+// ConfirmAfterChecks returns how many consecutive failures mark the monitor
+// as down.
+func (m *Monitor) ConfirmAfterChecks() int {
+	if m.ConfirmAfter <= 0 {
+		return 1
+	}
+	return m.ConfirmAfter
+}
+
+// AcceptsStatus reports whether the HTTP status code counts as up. Without an
+// explicit list any 2xx or 3xx response is accepted.
+func (m *Monitor) AcceptsStatus(code int) bool {
+	ranges, err := parseStatusRanges(m.AcceptedStatus)
+	if err != nil {
+		return false
+	}
+	for _, r := range ranges {
+		if code >= r[0] && code <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// parseStatusRanges parses a list such as "200,204,300-399".
+func parseStatusRanges(s string) ([][2]int, error) {
+	if strings.TrimSpace(s) == "" {
+		return [][2]int{{200, 399}}, nil
+	}
+
+	var ranges [][2]int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		lo, hi, isRange := strings.Cut(part, "-")
+		if !isRange {
+			hi = lo
+		}
+		from, err1 := strconv.Atoi(strings.TrimSpace(lo))
+		to, err2 := strconv.Atoi(strings.TrimSpace(hi))
+		if err1 != nil || err2 != nil || from < 100 || to > 599 || from > to {
+			return nil, errors.New("accepted_status_codes must be a comma-separated list of codes or ranges between 100-599, e.g. \"200,204,300-399\"")
+		}
+		ranges = append(ranges, [2]int{from, to})
+	}
+	return ranges, nil
+}
 
+// HasBodyAssertions reports whether the check must download the response
+// body to decide if the monitor is up.
+func (m *Monitor) HasBodyAssertions() bool {
+	return m.Keyword != "" || m.BodyRegex != "" || len(m.JSONAssertions) > 0
+}
+
+func (m *Monitor) validateTCP() error {
+	host, port, err := net.SplitHostPort(m.URL)
+	if err != nil {
+		return errors.New("address must be in host:port format")
+	}
+
+	if host == "" {
+		return errors.New("address must have a valid host")
+	}
+
+	if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+		return errors.New("port must be between 1-65535")
+	}
+
+	if len(m.ExpectedBanner) > 1024 {
+		return errors.New("expected_banner must be at most 1024 characters")
+	}
+
+	return nil
+}
+
+func (m *Monitor) validateDNS() error {
+	if len(m.URL) < 1 || len(m.URL) > 253 || strings.ContainsAny(m.URL, "/: ") {
+		return errors.New("hostname must be a bare domain name between 1-253 characters")
+	}
+
+	m.DNSRecordType = strings.ToUpper(m.DNSRecordType)
+	if m.DNSRecordType == "" {
+		m.DNSRecordType = "A"
+	}
+	if !dnsRecordTypes[m.DNSRecordType] {
+		return errors.New("dns_record_type must be one of: A, AAAA, CNAME, MX, TXT")
+	}
+
+	if m.DNSResolver != "" {
+		if _, _, err := net.SplitHostPort(m.DNSResolver); err != nil {
+			m.DNSResolver = net.JoinHostPort(m.DNSResolver, "53")
+		}
+		host, port, err := net.SplitHostPort(m.DNSResolver)
+		if err != nil || host == "" {
+			return errors.New("dns_resolver must be an address in host or host:port format")
+		}
+		if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+			return errors.New("dns_resolver port must be between 1-65535")
+		}
+	}
+
+	if len(m.DNSExpected) > 2048 {
+		return errors.New("dns_expected must be at most 2048 characters")
+	}
+
+	return nil
+}
@@ -5,4 +5,7 @@ type DailyStat struct {
 	Date       string  `json:"date"`
 	UptimePct  float64 `json:"uptime_pct"`
 	AvgLatency int64   `json:"avg_latency"`
+	AvgTimings Timings `json:"avg_timings"`
+
+	MaintenanceCount int64 `json:"maintenance_count"` // checks excluded from UptimePct
 }
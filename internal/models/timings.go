@@ -0,0 +1,12 @@
+package models
+
+// Timings breaks a check's latency down into phases, in milliseconds. Phases
+// that did not happen (e.g. DNS and connect on a reused connection, or TLS on
+// plain HTTP) are zero.
+type Timings struct {
+	DNS      int64 `json:"dns"`
+	Connect  int64 `json:"connect"`
+	TLS      int64 `json:"tls"`
+	TTFB     int64 `json:"ttfb"`
+	Transfer int64 `json:"transfer"`
+}
@@ -1,20 +1,192 @@
 package models
 
-import "errors"
+import (
+	"errors"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+)
 
+// Notification channel types. A webhook is delivered in the format of the
+// service it points at.
+const (
+	WebhookTypeDiscord   = "discord"
+	WebhookTypeSlack     = "slack"
+	WebhookTypeTeams     = "teams"
+	WebhookTypeTelegram  = "telegram"
+	WebhookTypeEmail     = "email"
+	WebhookTypePagerDuty = "pagerduty"
+	WebhookTypeOpsgenie  = "opsgenie"
+	WebhookTypeGeneric   = "generic"
+)
+
+// Default API endpoints for channels that do not name their own, such as
+// a self-hosted Bot API server or Opsgenie's EU region.
+const (
+	DefaultTelegramURL  = "https://api.telegram.org"
+	DefaultPagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+	DefaultOpsgenieURL  = "https://api.opsgenie.com"
+)
+
+// Webhook is a notification channel. Settings holds type-specific options,
+// such as the bot token and chat ID of a Telegram channel, the SMTP server
+// of an email channel or the routing key of a PagerDuty service. Headers
+// are sent with generic webhooks.
 type Webhook struct {
-	ID      int64  `json:"id"`
-	Name    string `json:"name"`
-	URL     string `json:"url"`
-	Enabled bool   `json:"enabled"`
+	ID       int64             `json:"id"`
+	Name     string            `json:"name"`
+	Type     string            `json:"type"`
+	URL      string            `json:"url"`
+	Settings map[string]string `json:"settings,omitempty"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Enabled  bool              `json:"enabled"`
+}
+
+var webhookMethods = map[string]bool{
+	"POST":  true,
+	"PUT":   true,
+	"PATCH": true,
 }
 
 func (w *Webhook) Validate() error {
 	if len(w.Name) < 1 || len(w.Name) > 200 {
 		return errors.New("name must be between 1-200 characters")
 	}
+
+	if len(w.Settings) > 20 {
+		return errors.New("at most 20 settings are allowed")
+	}
+	for key, value := range w.Settings {
+		if key == "" || len(key) > 100 || len(value) > 16384 {
+			return errors.New("settings must have names of 1-100 characters and values of at most 16384 characters")
+		}
+	}
+
+	if w.Type == "" {
+		w.Type = WebhookTypeDiscord
+	}
+	if len(w.Headers) > 0 && w.Type != WebhookTypeGeneric {
+		return errors.New("headers are only supported for generic webhooks")
+	}
+	switch w.Type {
+	case WebhookTypeDiscord, WebhookTypeSlack, WebhookTypeTeams:
+		return w.validateURL()
+	case WebhookTypeTelegram:
+		if w.URL == "" {
+			w.URL = DefaultTelegramURL
+		}
+		if w.Settings["bot_token"] == "" || w.Settings["chat_id"] == "" {
+			return errors.New("telegram channels need bot_token and chat_id settings")
+		}
+		return w.validateURL()
+	case WebhookTypeEmail:
+		return w.validateEmail()
+	case WebhookTypePagerDuty:
+		if w.URL == "" {
+			w.URL = DefaultPagerDutyURL
+		}
+		if w.Settings["routing_key"] == "" {
+			return errors.New("pagerduty channels need a routing_key setting")
+		}
+		return w.validateURL()
+	case WebhookTypeOpsgenie:
+		if w.URL == "" {
+			w.URL = DefaultOpsgenieURL
+		}
+		if w.Settings["api_key"] == "" {
+			return errors.New("opsgenie channels need an api_key setting")
+		}
+		return w.validateURL()
+	case WebhookTypeGeneric:
+		return w.validateGeneric()
+	default:
+		return errors.New("type must be one of: discord, slack, teams, telegram, email, pagerduty, opsgenie, generic")
+	}
+}
+
+func (w *Webhook) validateURL() error {
 	if len(w.URL) < 1 || len(w.URL) > 2048 {
 		return errors.New("URL must be between 1-2048 characters")
 	}
+	if u, err := url.Parse(w.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("URL must be an http or https URL")
+	}
 	return nil
 }
+
+// validateGeneric checks a generic webhook's method setting (POST by
+// default) and headers. The body template is checked by the notifier,
+// which owns the template functions.
+func (w *Webhook) validateGeneric() error {
+	if method := strings.ToUpper(w.Settings["method"]); method != "" {
+		if !webhookMethods[method] {
+			return errors.New("method must be one of: POST, PUT, PATCH")
+		}
+		w.Settings["method"] = method
+	}
+
+	if len(w.Headers) > 50 {
+		return errors.New("at most 50 headers are allowed")
+	}
+	for name, value := range w.Headers {
+		if name == "" || strings.ContainsAny(name, " :\r\n") || strings.ContainsAny(value, "\r\n") {
+			return errors.New("headers must have non-empty names without spaces, colons or line breaks")
+		}
+		if len(name)+len(value) > 8192 {
+			return errors.New("each header must be at most 8192 characters")
+		}
+	}
+	return w.validateURL()
+}
+
+// validateEmail checks an email channel's SMTP settings: host, port
+// (default 587), starttls ("true" by default), optional username and
+// password, from, and a comma-separated list of recipients in to.
+func (w *Webhook) validateEmail() error {
+	if w.URL != "" {
+		return errors.New("email channels do not take a URL")
+	}
+	s := w.Settings
+	if s["host"] == "" || strings.ContainsAny(s["host"], " /:") {
+		return errors.New("email channels need an SMTP host setting")
+	}
+	if port := s["port"]; port != "" {
+		if p, err := strconv.Atoi(port); err != nil || p < 1 || p > 65535 {
+			return errors.New("port must be between 1-65535")
+		}
+	}
+	if tls := s["starttls"]; tls != "" {
+		enabled, err := strconv.ParseBool(tls)
+		if err != nil {
+			return errors.New("starttls must be true or false")
+		}
+		s["starttls"] = strconv.FormatBool(enabled) // as the notifier expects
+	}
+	if s["password"] != "" && s["username"] == "" {
+		return errors.New("password needs a username")
+	}
+	if _, err := mail.ParseAddress(s["from"]); err != nil {
+		return errors.New("from must be a valid email address")
+	}
+	if _, err := EmailRecipients(s["to"]); err != nil {
+		return err
+	}
+	return nil
+}
+
+// EmailRecipients parses an email channel's comma-separated recipients.
+func EmailRecipients(list string) ([]string, error) {
+	addresses, err := mail.ParseAddressList(list)
+	if err != nil || len(addresses) == 0 {
+		return nil, errors.New("to must be a comma-separated list of email addresses")
+	}
+	if len(addresses) > 50 {
+		return nil, errors.New("at most 50 recipients are allowed")
+	}
+	recipients := make([]string, 0, len(addresses))
+	for _, a := range addresses {
+		recipients = append(recipients, a.Address)
+	}
+	return recipients, nil
+}
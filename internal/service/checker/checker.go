@@ -3,80 +3,52 @@ package checker
 import (
 	"context"
 	"net"
-	"net/http"
-	"syscall"
 	"time"
+
+	"go-sentinel/internal/models"
 )
 
 type CheckResult struct {
-	StatusCode int
-	Latency    int64
-	IsUp       bool
-}
+	StatusCode  int
+	Latency     int64
+	Timings     models.Timings
+	IsUp        bool
+	Certificate *models.Certificate
+	Assertion   string // description of the failed body assertion
 
-var httpClient = &http.Client{
-	Timeout: 5 * time.Second,
-	Transport: &http.Transport{
-		MaxIdleConns:      100,
-		IdleConnTimeout:   90 * time.Second,
-		DisableKeepAlives: false,
-		DialContext: (&net.Dialer{
-			Timeout:   5 * time.Second,
-			KeepAlive: 30 * time.Second,
-			Control: func(network, address string, c syscall.RawConn) error {
-				host, _, err := net.SplitHostPort(address)
-				if err != nil {
-					return err
-				}
-				ip := net.ParseIP(host)
-				if ip != nil {
-					if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() {
-						return net.InvalidAddrError("connection to private, loopback, or link-local IP is prohibited")
-					}
-				}
-				return nil
-			},
-		}).DialContext,
-	},
+	// ErrorCategory and ErrorMessage explain why a failed check is down.
+	ErrorCategory string
+	ErrorMessage  string
 }
 
-func PerformHTTPCheck(ctx context.Context, url string) CheckResult {
-	start := time.Now()
-	
-	req, err := http.NewRequestWithContext(ctx, "HEAD", url, nil)
-	if err != nil {
-		return CheckResult{IsUp: false}
-	}
+// Checker probes a single monitor and reports whether it is up.
+type Checker interface {
+	Check(ctx context.Context, m models.Monitor) CheckResult
+}
 
-	resp, err := httpClient.Do(req)
-	latency := time.Since(start).Milliseconds()
+var checkers = map[string]Checker{
+	models.MonitorTypeHTTP: httpChecker{},
+	models.MonitorTypeTCP:  tcpChecker{},
+	models.MonitorTypeDNS:  dnsChecker{},
+	models.MonitorTypePush: pushChecker{},
+}
 
-	if err != nil {
-		start = time.Now()
-		getReq, getErr := http.NewRequestWithContext(ctx, "GET", url, nil)
-		if getErr != nil {
-			return CheckResult{
-				StatusCode: 0,
-				Latency:    latency,
-				IsUp:       false,
-			}
-		}
-		resp, err = httpClient.Do(getReq)
-		latency = time.Since(start).Milliseconds()
-		
-		if err != nil {
-			return CheckResult{
-				StatusCode: 0,
-				Latency:    latency,
-				IsUp:       false,
-			}
-		}
+// Perform dispatches the monitor to the checker registered for its type,
+// bounding the whole check by the monitor's timeout.
+func Perform(ctx context.Context, m models.Monitor) CheckResult {
+	c, ok := checkers[m.Type]
+	if !ok {
+		c = checkers[models.MonitorTypeHTTP]
 	}
-	defer resp.Body.Close()
 
-	return CheckResult{
-		StatusCode: resp.StatusCode,
-		Latency:    latency,
-		IsUp:       resp.StatusCode >= 200 && resp.StatusCode < 400,
-	}
+	ctx, cancel := context.WithTimeout(ctx, m.TimeoutDuration())
+	defer cancel()
+	return c.Check(ctx, m)
+}
+
+// dialer holds the shared dial settings; connections go through
+// dialContext, which adds the network policy.
+var dialer = &net.Dialer{
+	Timeout:   models.MaxTimeout,
+	KeepAlive: 30 * time.Second,
 }
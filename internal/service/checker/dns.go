@@ -0,0 +1,114 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"go-sentinel/internal/models"
+)
+
+type dnsChecker struct{}
+
+// Check resolves the monitor's hostname for the configured record type. The
+// monitor is down when the lookup fails, returns no records, or any of the
+// comma-separated expected values is missing from the answer set.
+func (dnsChecker) Check(ctx context.Context, m models.Monitor) CheckResult {
+	start := time.Now()
+	answers, err := lookup(ctx, newResolver(m.DNSResolver), m.DNSRecordType, m.URL)
+	latency := time.Since(start).Milliseconds()
+	if err != nil {
+		category, message := classifyError(err)
+		return failure(latency, category, message)
+	}
+	if len(answers) == 0 {
+		return failure(latency, models.ErrorDNS, "no "+m.DNSRecordType+" records returned")
+	}
+
+	if missing := missingExpected(answers, m.DNSExpected); missing != "" {
+		return failure(latency, models.ErrorAssertion,
+			fmt.Sprintf("expected %s not in answer set [%s]", missing, strings.Join(answers, ", ")))
+	}
+	return CheckResult{Latency: latency, Timings: models.Timings{DNS: latency}, IsUp: true}
+}
+
+func newResolver(address string) *net.Resolver {
+	if address == "" {
+		return net.DefaultResolver
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			return dialContext(ctx, network, address)
+		},
+	}
+}
+
+func lookup(ctx context.Context, r *net.Resolver, recordType, host string) ([]string, error) {
+	var answers []string
+	switch recordType {
+	case "AAAA":
+		ips, err := r.LookupIP(ctx, "ip6", host)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			answers = append(answers, ip.String())
+		}
+	case "CNAME":
+		cname, err := r.LookupCNAME(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		answers = append(answers, cname)
+	case "MX":
+		mxs, err := r.LookupMX(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, mx := range mxs {
+			answers = append(answers, mx.Host)
+		}
+	case "TXT":
+		txts, err := r.LookupTXT(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		answers = txts
+	default:
+		ips, err := r.LookupIP(ctx, "ip4", host)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			answers = append(answers, ip.String())
+		}
+	}
+	return answers, nil
+}
+
+// missingExpected returns the first expected value absent from the answers,
+// or "" when all are present.
+func missingExpected(answers []string, expected string) string {
+	if strings.TrimSpace(expected) == "" {
+		return ""
+	}
+
+	got := make(map[string]bool, len(answers))
+	for _, a := range answers {
+		got[normalizeDNSValue(a)] = true
+	}
+	for _, want := range strings.Split(expected, ",") {
+		want = normalizeDNSValue(want)
+		if want != "" && !got[want] {
+			return want
+		}
+	}
+	return ""
+}
+
+func normalizeDNSValue(v string) string {
+	return strings.ToLower(strings.TrimSuffix(strings.TrimSpace(v), "."))
+}
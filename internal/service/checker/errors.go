@@ -0,0 +1,70 @@
+package checker
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/url"
+	"syscall"
+
+	"go-sentinel/internal/models"
+)
+
+const maxErrorMessage = 500
+
+// PolicyError is returned when the network policy refuses a connection.
+type PolicyError struct {
+	msg string
+}
+
+func (e *PolicyError) Error() string { return e.msg }
+
+// failure builds a down result with the given category and message.
+func failure(latency int64, category, message string) CheckResult {
+	if len(message) > maxErrorMessage {
+		message = message[:maxErrorMessage]
+	}
+	return CheckResult{
+		Latency:       latency,
+		IsUp:          false,
+		ErrorCategory: category,
+		ErrorMessage:  message,
+	}
+}
+
+// classifyError maps a network error to a failure category, so DNS
+// failures, refused connections, TLS problems and timeouts can be told apart.
+func classifyError(err error) (string, string) {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	message := err.Error()
+
+	var policyErr *PolicyError
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var certErr *tls.CertificateVerificationError
+	var unknownAuthority x509.UnknownAuthorityError
+	var invalidCert x509.CertificateInvalidError
+	var hostnameErr x509.HostnameError
+	var recordErr tls.RecordHeaderError
+
+	switch {
+	case errors.As(err, &policyErr):
+		return models.ErrorBlocked, message
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return models.ErrorTimeout, message
+	case errors.As(err, &dnsErr):
+		return models.ErrorDNS, message
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return models.ErrorRefused, message
+	case errors.As(err, &certErr), errors.As(err, &unknownAuthority), errors.As(err, &invalidCert),
+		errors.As(err, &hostnameErr), errors.As(err, &recordErr):
+		return models.ErrorTLS, message
+	default:
+		return models.ErrorConnection, message
+	}
+}
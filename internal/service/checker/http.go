@@ -0,0 +1,180 @@
+package checker
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"regexp"
+	"strings"
+	"time"
+
+	"go-sentinel/internal/assertion"
+	"go-sentinel/internal/models"
+)
+
+var httpClient = &http.Client{
+	Timeout: models.MaxTimeout,
+	Transport: &http.Transport{
+		MaxIdleConns:      100,
+		IdleConnTimeout:   90 * time.Second,
+		DisableKeepAlives: false,
+		DialContext:       dialContext,
+	},
+}
+
+const maxBodyBytes = 1 << 20
+
+type httpChecker struct{}
+
+// Check sends the monitor's configured request. Without an explicit method
+// it probes with HEAD and falls back to GET when HEAD fails; monitors with
+// body assertions use GET. At most maxBodyBytes of the response are read,
+// which also measures the transfer phase.
+func (httpChecker) Check(ctx context.Context, m models.Monitor) CheckResult {
+	needsBody := m.HasBodyAssertions()
+
+	method := m.Method
+	if method == "" {
+		method = http.MethodHead
+		if needsBody {
+			method = http.MethodGet
+		}
+	}
+
+	start := time.Now()
+	phases := &phaseTimer{}
+	resp, err := doRequest(ctx, m, method, phases)
+	latency := time.Since(start).Milliseconds()
+
+	if err != nil && m.Method == "" && method == http.MethodHead {
+		start = time.Now()
+		phases = &phaseTimer{}
+		resp, err = doRequest(ctx, m, http.MethodGet, phases)
+		latency = time.Since(start).Milliseconds()
+	}
+	if err != nil {
+		category, message := classifyError(err)
+		failed := failure(latency, category, message)
+		failed.Timings = phases.timings(time.Time{})
+		return failed
+	}
+	defer resp.Body.Close()
+
+	body, readErr := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+
+	result := CheckResult{
+		StatusCode:  resp.StatusCode,
+		Latency:     latency,
+		Timings:     phases.timings(time.Now()),
+		IsUp:        m.AcceptsStatus(resp.StatusCode),
+		Certificate: certificateFromState(resp.TLS),
+	}
+	if !result.IsUp {
+		result.ErrorCategory = models.ErrorHTTPStatus
+		result.ErrorMessage = "unexpected status " + statusText(resp.StatusCode)
+	}
+
+	// An expired certificate already fails verification in the handshake
+	// and is reported as a TLS error there.
+	if result.Certificate != nil {
+		result.Certificate.MonitorID = m.ID
+	}
+
+	if result.IsUp && needsBody {
+		if readErr != nil {
+			category, message := classifyError(readErr)
+			failed := failure(latency, category, message)
+			failed.StatusCode, failed.Timings, failed.Certificate = result.StatusCode, result.Timings, result.Certificate
+			return failed
+		}
+		if ok, failed := bodyMatches(m, body); !ok {
+			result.IsUp = false
+			result.Assertion = failed
+			result.ErrorCategory = models.ErrorAssertion
+			result.ErrorMessage = failed
+		}
+	}
+	return result
+}
+
+func doRequest(ctx context.Context, m models.Monitor, method string, phases *phaseTimer) (*http.Response, error) {
+	var body io.Reader
+	if m.Body != "" && method != http.MethodHead {
+		body = strings.NewReader(m.Body)
+	}
+
+	ctx = httptrace.WithClientTrace(ctx, phases.trace())
+	req, err := http.NewRequestWithContext(ctx, method, m.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range m.Headers {
+		if strings.EqualFold(name, "Host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+
+	return httpClient.Do(req)
+}
+
+func statusText(code int) string {
+	if text := http.StatusText(code); text != "" {
+		return fmt.Sprintf("%d %s", code, text)
+	}
+	return fmt.Sprint(code)
+}
+
+func bodyMatches(m models.Monitor, body []byte) (bool, string) {
+	if m.Keyword != "" {
+		found := bytes.Contains(body, []byte(m.Keyword))
+		if found && m.InvertKeyword {
+			return false, fmt.Sprintf("body contains %q", m.Keyword)
+		}
+		if !found && !m.InvertKeyword {
+			return false, fmt.Sprintf("body does not contain %q", m.Keyword)
+		}
+	}
+
+	if m.BodyRegex != "" {
+		re, err := regexp.Compile(m.BodyRegex)
+		if err != nil || !re.Match(body) {
+			return false, fmt.Sprintf("body does not match /%s/", m.BodyRegex)
+		}
+	}
+
+	assertions, err := assertion.ParseAll(m.JSONAssertions)
+	if err != nil {
+		return false, err.Error()
+	}
+	return assertion.EvaluateBody(body, assertions)
+}
+
+// certificateFromState summarises the verified peer chain. NotAfter is the
+// earliest expiry in the chain so an expiring intermediate is caught too.
+func certificateFromState(state *tls.ConnectionState) *models.Certificate {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return nil
+	}
+
+	leaf := state.PeerCertificates[0]
+	cert := &models.Certificate{
+		Subject:   leaf.Subject.CommonName,
+		Issuer:    leaf.Issuer.CommonName,
+		SANs:      append([]string{}, leaf.DNSNames...),
+		NotBefore: leaf.NotBefore,
+		NotAfter:  leaf.NotAfter,
+		CheckedAt: time.Now(),
+	}
+	for _, c := range state.PeerCertificates[1:] {
+		if c.NotAfter.Before(cert.NotAfter) {
+			cert.NotAfter = c.NotAfter
+		}
+	}
+	return cert
+}
@@ -0,0 +1,205 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"syscall"
+
+	"go-sentinel/internal/models"
+)
+
+// Policy decides which addresses checks may connect to. Deny rules win over
+// allow rules; addresses matching neither are allowed unless they are
+// loopback, private, link-local or unspecified.
+type Policy struct {
+	AllowNetworks []*net.IPNet
+	DenyNetworks  []*net.IPNet
+	AllowHosts    []string
+	DenyHosts     []string
+}
+
+var policy atomic.Pointer[Policy]
+
+func init() {
+	policy.Store(&Policy{})
+}
+
+// SetPolicy replaces the network policy used by all check types.
+func SetPolicy(p *Policy) {
+	if p == nil {
+		p = &Policy{}
+	}
+	policy.Store(p)
+}
+
+func CurrentPolicy() *Policy {
+	return policy.Load()
+}
+
+// ParsePolicy builds a policy from comma-separated allow and deny lists.
+// Entries are IP addresses, CIDR ranges or hostnames; a leading "*." matches
+// any subdomain.
+func ParsePolicy(allow, deny string) (*Policy, error) {
+	p := &Policy{}
+	var err error
+	if p.AllowNetworks, p.AllowHosts, err = parseRules(allow); err != nil {
+		return nil, err
+	}
+	if p.DenyNetworks, p.DenyHosts, err = parseRules(deny); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func parseRules(list string) ([]*net.IPNet, []string, error) {
+	var networks []*net.IPNet
+	var hosts []string
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, "/") {
+			_, n, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid CIDR %q", entry)
+			}
+			networks = append(networks, n)
+			continue
+		}
+		if ip := net.ParseIP(entry); ip != nil {
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			networks = append(networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		hosts = append(hosts, strings.TrimSuffix(entry, "."))
+	}
+	return networks, hosts, nil
+}
+
+// hostDecision applies the hostname rules. It returns allowed=true when the
+// host is explicitly allow-listed, which lifts the block on private
+// addresses but not the deny networks.
+func (p *Policy) hostDecision(host string) (allowed bool, err error) {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if matchHost(p.DenyHosts, host) {
+		return false, &PolicyError{fmt.Sprintf("connection to %s is denied by policy", host)}
+	}
+	return matchHost(p.AllowHosts, host), nil
+}
+
+// checkIP applies the IP rules. hostAllowed reports whether the hostname
+// being dialled is allow-listed.
+func (p *Policy) checkIP(ip net.IP, hostAllowed bool) error {
+	for _, n := range p.DenyNetworks {
+		if n.Contains(ip) {
+			return &PolicyError{fmt.Sprintf("connection to %s is denied by policy", ip)}
+		}
+	}
+	if hostAllowed {
+		return nil
+	}
+	for _, n := range p.AllowNetworks {
+		if n.Contains(ip) {
+			return nil
+		}
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() {
+		return &PolicyError{"connection to private, loopback, or link-local IP is prohibited"}
+	}
+	return nil
+}
+
+func matchHost(patterns []string, host string) bool {
+	for _, p := range patterns {
+		if suffix, ok := strings.CutPrefix(p, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+		} else if host == p {
+			return true
+		}
+	}
+	return false
+}
+
+// dialContext connects under the current policy. Hostname rules are checked
+// before resolution and IP rules on every address actually dialled, so DNS
+// answers pointing at internal addresses are caught too.
+func dialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	p := CurrentPolicy()
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed, err := p.hostDecision(host)
+	if err != nil {
+		return nil, err
+	}
+
+	d := *dialer
+	d.Control = func(network, address string, c syscall.RawConn) error {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+		if ip := net.ParseIP(host); ip != nil {
+			return p.checkIP(ip, allowed)
+		}
+		return nil
+	}
+	return d.DialContext(ctx, network, address)
+}
+
+// TargetHost returns the host a monitor's check connects to, or "" when the
+// check makes no outbound connection of its own.
+func TargetHost(m models.Monitor) string {
+	switch m.Type {
+	case models.MonitorTypeHTTP:
+		u, err := url.Parse(m.URL)
+		if err != nil {
+			return ""
+		}
+		return u.Hostname()
+	case models.MonitorTypeTCP:
+		host, _, _ := net.SplitHostPort(m.URL)
+		return host
+	case models.MonitorTypeDNS:
+		host, _, _ := net.SplitHostPort(m.DNSResolver)
+		return host
+	}
+	return ""
+}
+
+// ValidateTarget rejects monitors whose target is denied by the current
+// policy. Hostnames are not resolved here; their addresses are enforced when
+// the check connects.
+func ValidateTarget(m models.Monitor) error {
+	if m.Type == models.MonitorTypeHTTP {
+		if _, err := url.Parse(m.URL); err != nil {
+			return err
+		}
+	}
+	host := TargetHost(m)
+	if host == "" {
+		return nil
+	}
+
+	p := CurrentPolicy()
+	allowed, err := p.hostDecision(host)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return p.checkIP(ip, allowed)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package checker
+
+import (
+	"context"
+	"time"
+
+	"go-sentinel/internal/models"
+)
+
+type pushChecker struct{}
+
+// Check is passive: the monitored job reports in via its push URL and the
+// monitor is up as long as the last heartbeat is recent enough.
+func (pushChecker) Check(ctx context.Context, m models.Monitor) CheckResult {
+	if !m.HeartbeatOverdue(time.Now()) {
+		return CheckResult{IsUp: true}
+	}
+	if m.LastPushAt == nil {
+		return failure(0, models.ErrorHeartbeat, "no heartbeat received yet")
+	}
+	return failure(0, models.ErrorHeartbeat, "no heartbeat since "+m.LastPushAt.UTC().Format(time.RFC3339))
+}
@@ -0,0 +1,49 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"go-sentinel/internal/models"
+)
+
+const maxBannerBytes = 4096
+
+type tcpChecker struct{}
+
+// Check opens a TCP connection to the monitor's host:port. Latency is the
+// connect time only; when an expected banner is configured the first bytes
+// sent by the server must contain it.
+func (tcpChecker) Check(ctx context.Context, m models.Monitor) CheckResult {
+	start := time.Now()
+	conn, err := dialContext(ctx, "tcp", m.URL)
+	latency := time.Since(start).Milliseconds()
+	if err != nil {
+		category, message := classifyError(err)
+		return failure(latency, category, message)
+	}
+	defer conn.Close()
+
+	timings := models.Timings{Connect: latency}
+	if m.ExpectedBanner == "" {
+		return CheckResult{Latency: latency, Timings: timings, IsUp: true}
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetReadDeadline(deadline)
+	}
+	buf := make([]byte, maxBannerBytes)
+	n, err := io.ReadAtLeast(conn, buf, len(m.ExpectedBanner))
+	if err != nil && n == 0 {
+		category, message := classifyError(err)
+		return failure(latency, category, "no banner received: "+message)
+	}
+
+	if !strings.Contains(string(buf[:n]), m.ExpectedBanner) {
+		return failure(latency, models.ErrorAssertion, fmt.Sprintf("banner does not contain %q", m.ExpectedBanner))
+	}
+	return CheckResult{Latency: latency, Timings: timings, IsUp: true}
+}
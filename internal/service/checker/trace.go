@@ -0,0 +1,71 @@
+package checker
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"go-sentinel/internal/models"
+)
+
+// phaseTimer records when each phase of an HTTP request starts and ends.
+// Callbacks may fire from dialer goroutines, hence the mutex.
+type phaseTimer struct {
+	mu           sync.Mutex
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	gotConn      time.Time
+	firstByte    time.Time
+}
+
+func (p *phaseTimer) mark(t *time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if t.IsZero() {
+		*t = time.Now()
+	}
+}
+
+func (p *phaseTimer) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:     func(httptrace.DNSStartInfo) { p.mark(&p.dnsStart) },
+		DNSDone:      func(httptrace.DNSDoneInfo) { p.mark(&p.dnsDone) },
+		ConnectStart: func(string, string) { p.mark(&p.connectStart) },
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				p.mark(&p.connectDone)
+			}
+		},
+		TLSHandshakeStart:    func() { p.mark(&p.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { p.mark(&p.tlsDone) },
+		GotConn:              func(httptrace.GotConnInfo) { p.mark(&p.gotConn) },
+		GotFirstResponseByte: func() { p.mark(&p.firstByte) },
+	}
+}
+
+// timings converts the recorded marks into phase durations. TTFB runs from
+// obtaining a connection to the first response byte, so it covers sending
+// the request and server processing time; transfer runs until bodyDone.
+func (p *phaseTimer) timings(bodyDone time.Time) models.Timings {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return models.Timings{
+		DNS:      span(p.dnsStart, p.dnsDone),
+		Connect:  span(p.connectStart, p.connectDone),
+		TLS:      span(p.tlsStart, p.tlsDone),
+		TTFB:     span(p.gotConn, p.firstByte),
+		Transfer: span(p.firstByte, bodyDone),
+	}
+}
+
+func span(start, end time.Time) int64 {
+	if start.IsZero() || end.IsZero() || end.Before(start) {
+		return 0
+	}
+	return end.Sub(start).Milliseconds()
+}
@@ -0,0 +1,53 @@
+package monitor
+
+import "time"
+
+// A monitor is flapping once it has changed state flapThreshold times
+// within flapWindow. While flapping its individual transitions are not
+// announced; it settles after holding one state for flapSettle.
+const (
+	flapThreshold = 5
+	flapWindow    = 30 * time.Minute
+	flapSettle    = 15 * time.Minute
+)
+
+// flapHistory holds the times of a monitor's most recent state changes,
+// newest first. It is an array so monitorState stays comparable.
+type flapHistory [flapThreshold]time.Time
+
+func (h *flapHistory) record(at time.Time) {
+	copy(h[1:], h[:len(h)-1])
+	h[0] = at
+}
+
+// full reports whether every recorded change falls within flapWindow.
+func (h *flapHistory) full(now time.Time) bool {
+	oldest := h[len(h)-1]
+	return !oldest.IsZero() && now.Sub(oldest) <= flapWindow
+}
+
+type flapChange int
+
+const (
+	flapUnchanged flapChange = iota
+	flapStarted
+	flapStopped
+)
+
+// applyFlapping records a state change and decides whether the monitor
+// started or stopped flapping with this check.
+func applyFlapping(next *monitorState, changed bool, now time.Time) flapChange {
+	if changed {
+		next.changes.record(now)
+	}
+	switch {
+	case !next.flapping && changed && next.changes.full(now):
+		next.flapping = true
+		return flapStarted
+	case next.flapping && now.Sub(next.since) >= flapSettle:
+		next.flapping = false
+		next.changes = flapHistory{}
+		return flapStopped
+	}
+	return flapUnchanged
+}
@@ -0,0 +1,45 @@
+package monitor
+
+import (
+	"context"
+	"errors"
+
+	"go-sentinel/internal/models"
+	"go-sentinel/internal/service/checker"
+)
+
+var (
+	// ErrStopped is returned for checks requested after Stop.
+	ErrStopped = errors.New("worker stopped")
+	// ErrBusy is returned when a manual check could not get a slot in time.
+	ErrBusy = errors.New("monitor or host busy")
+)
+
+// CheckNow runs a monitor's check immediately, outside the schedule, and
+// handles the result exactly like a scheduled check: it is saved, the
+// monitor's state is updated and notifications fire. It first waits for a
+// scheduled check of the same monitor to finish and for a free slot on the
+// target host, for up to the monitor's timeout or until ctx is done. Once
+// started the check runs to completion even if ctx is cancelled, so a
+// client going away does not record a false failure. The monitor's
+// schedule is left alone.
+func (w *Worker) CheckNow(ctx context.Context, m models.Monitor) (models.Check, *models.Certificate, error) {
+	waitCtx, cancel := context.WithTimeout(ctx, m.TimeoutDuration())
+	release, err := w.hold(waitCtx, m)
+	cancel()
+	if err != nil {
+		return models.Check{}, nil, err
+	}
+	defer release()
+
+	w.states.LoadOrStore(m.ID, savedState(m))
+	run := runCheck(context.WithoutCancel(ctx), w.db, &w.states, m)
+	return run.check, run.certificate, nil
+}
+
+// DryRun checks a monitor configuration without saving or notifying
+// anything, so it can be tried before the monitor is created.
+func DryRun(ctx context.Context, m models.Monitor) (models.Check, *models.Certificate) {
+	result := checker.Perform(ctx, m)
+	return newCheck(m, result), result.Certificate
+}
@@ -0,0 +1,346 @@
+package monitor
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-sentinel/internal/models"
+	"go-sentinel/internal/service/checker"
+)
+
+const (
+	DefaultWorkers      = 32
+	DefaultQueueSize    = 1024
+	DefaultPerHostLimit = 4
+)
+
+// lateAfter is how far past its due time a check may start before it is
+// counted as late.
+const lateAfter = 5 * time.Second
+
+// Config sizes the pool that runs checks. Zero values use the defaults.
+type Config struct {
+	Workers      int // checks running at once
+	QueueSize    int // due checks waiting for a free worker
+	PerHostLimit int // checks running at once against the same host
+}
+
+func (c Config) withDefaults() Config {
+	if c.Workers <= 0 {
+		c.Workers = DefaultWorkers
+	}
+	if c.QueueSize <= 0 {
+		c.QueueSize = DefaultQueueSize
+	}
+	if c.PerHostLimit <= 0 {
+		c.PerHostLimit = DefaultPerHostLimit
+	}
+	return c
+}
+
+// Stats is a snapshot of the pool's load. Counters are totals since start.
+type Stats struct {
+	Workers    int   `json:"workers"`
+	QueueSize  int   `json:"queue_size"`
+	QueueDepth int   `json:"queue_depth"`
+	InFlight   int64 `json:"in_flight"`
+	Completed  int64 `json:"completed"`
+	Dropped    int64 `json:"dropped"`
+	Late       int64 `json:"late"`
+}
+
+type poolCounters struct {
+	inFlight  atomic.Int64
+	completed atomic.Int64
+	dropped   atomic.Int64
+	late      atomic.Int64
+}
+
+// job is a due check waiting in the queue. A job waiting in line for a
+// slot carries the ones it already holds.
+type job struct {
+	monitor     models.Monitor
+	due         time.Time
+	monitorSlot func()
+	hostSlot    func()
+}
+
+// Stats reports the pool's current load. It is safe to call on a nil
+// Worker.
+func (w *Worker) Stats() Stats {
+	if w == nil {
+		return Stats{}
+	}
+	return Stats{
+		Workers:    w.cfg.Workers,
+		QueueSize:  w.cfg.QueueSize,
+		QueueDepth: len(w.jobs),
+		InFlight:   w.counters.inFlight.Load(),
+		Completed:  w.counters.completed.Load(),
+		Dropped:    w.counters.dropped.Load(),
+		Late:       w.counters.late.Load(),
+	}
+}
+
+// submit queues a check without blocking the scheduler. It reports false
+// when the queue is full and the check was dropped.
+func (w *Worker) submit(j job) bool {
+	select {
+	case w.jobs <- j:
+		return true
+	default:
+		w.counters.dropped.Add(1)
+		return false
+	}
+}
+
+func (w *Worker) work(ctx context.Context) {
+	defer w.workers.Done()
+	for {
+		if j, ok := w.nextReady(); ok {
+			select {
+			case <-w.stopping:
+				return
+			default:
+				w.execute(ctx, j)
+			}
+			continue
+		}
+
+		select {
+		case <-w.stopping:
+			return
+		case <-w.wake:
+		case j := <-w.jobs:
+			select {
+			case <-w.stopping:
+				return
+			default:
+				w.execute(ctx, j)
+			}
+		}
+	}
+}
+
+// resume hands a job that got its slots while waiting back to the pool.
+// It is called by whoever released the slot and must not block.
+func (w *Worker) resume(j job) {
+	w.readyMu.Lock()
+	w.ready = append(w.ready, j)
+	w.readyMu.Unlock()
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+// nextReady takes the oldest resumed job, waking another worker if more
+// are left.
+func (w *Worker) nextReady() (job, bool) {
+	w.readyMu.Lock()
+	defer w.readyMu.Unlock()
+	if len(w.ready) == 0 {
+		return job{}, false
+	}
+	j := w.ready[0]
+	w.ready = slices.Delete(w.ready, 0, 1)
+	if len(w.ready) > 0 {
+		select {
+		case w.wake <- struct{}{}:
+		default:
+		}
+	}
+	return j, true
+}
+
+// execute runs a queued check. If its monitor or host is busy the check
+// waits in line for the slot without holding up the worker, so one slow
+// host cannot stall checks against all the others.
+func (w *Worker) execute(ctx context.Context, j job) {
+	release, ok := w.claim(j)
+	if !ok {
+		return
+	}
+	defer release()
+
+	if time.Since(j.due) > lateAfter {
+		w.counters.late.Add(1)
+	}
+	w.counters.inFlight.Add(1)
+	run := runCheck(ctx, w.db, &w.states, j.monitor)
+	w.counters.inFlight.Add(-1)
+	w.counters.completed.Add(1)
+
+	select {
+	case w.finished <- finishedCheck{monitorID: j.monitor.ID, state: run.state}:
+	case <-w.stopping:
+	}
+}
+
+// claim takes the job's monitor and host slots. If one is taken the job
+// gets in line for it and claim reports false; once the slot passes to the
+// job it is resumed, holding the slots it has so far.
+func (w *Worker) claim(j job) (release func(), ok bool) {
+	// The callbacks may run as soon as the job is in line, so j is not
+	// touched here after that.
+	if j.monitorSlot == nil {
+		slot, waiting := w.checking.tryAcquire(monitorKey(j.monitor), func(slot func()) {
+			j.monitorSlot = slot
+			w.resume(j)
+		})
+		if waiting != nil {
+			return nil, false
+		}
+		j.monitorSlot = slot
+	}
+	if j.hostSlot == nil {
+		slot, waiting := w.hosts.tryAcquire(checker.TargetHost(j.monitor), func(slot func()) {
+			j.hostSlot = slot
+			w.resume(j)
+		})
+		if waiting != nil {
+			return nil, false
+		}
+		j.hostSlot = slot
+	}
+	monitorSlot, hostSlot := j.monitorSlot, j.hostSlot
+	return func() {
+		hostSlot()
+		monitorSlot()
+	}, true
+}
+
+// hold waits until the monitor is not being checked elsewhere and its host
+// has a free slot, so scheduled and manual checks of one monitor never
+// overlap. It returns ErrBusy if ctx is done first and ErrStopped if the
+// worker stops.
+func (w *Worker) hold(ctx context.Context, m models.Monitor) (release func(), err error) {
+	releaseMonitor, err := w.checking.acquire(ctx, w.stopping, monitorKey(m))
+	if err != nil {
+		return nil, err
+	}
+	releaseHost, err := w.hosts.acquire(ctx, w.stopping, checker.TargetHost(m))
+	if err != nil {
+		releaseMonitor()
+		return nil, err
+	}
+	return func() {
+		releaseHost()
+		releaseMonitor()
+	}, nil
+}
+
+func monitorKey(m models.Monitor) string {
+	return strconv.FormatInt(m.ID, 10)
+}
+
+// keyLimiter caps concurrent holders of each key, such as checks against
+// one host so a shared server is not hit by many monitors at once. Those
+// that find every slot taken wait in line, and a released slot passes
+// straight to the first in line. Keys are dropped when nobody uses them.
+type keyLimiter struct {
+	limit int
+	mu    sync.Mutex
+	slots map[string]*keySlot
+}
+
+type keySlot struct {
+	held    int
+	waiting []*keyWaiter // in arrival order
+}
+
+// keyWaiter is a place in line. granted is called with the slot's release
+// function once the slot is passed on to it; it must not block.
+type keyWaiter struct {
+	granted func(release func())
+}
+
+func newKeyLimiter(limit int) *keyLimiter {
+	return &keyLimiter{limit: limit, slots: make(map[string]*keySlot)}
+}
+
+// tryAcquire takes a slot for key if one is free. Otherwise it puts
+// granted in line and returns the waiter. An empty key is never limited.
+func (h *keyLimiter) tryAcquire(key string, granted func(release func())) (release func(), waiting *keyWaiter) {
+	if key == "" {
+		return func() {}, nil
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	slot := h.slots[key]
+	if slot == nil {
+		slot = &keySlot{}
+		h.slots[key] = slot
+	}
+	if slot.held < h.limit {
+		slot.held++
+		return h.releaser(key, slot), nil
+	}
+	waiting = &keyWaiter{granted: granted}
+	slot.waiting = append(slot.waiting, waiting)
+	return nil, waiting
+}
+
+// acquire blocks until a slot for key is free. It returns ErrBusy if ctx
+// is done first and ErrStopped if stop is closed.
+func (h *keyLimiter) acquire(ctx context.Context, stop <-chan struct{}, key string) (release func(), err error) {
+	granted := make(chan func(), 1)
+	release, waiting := h.tryAcquire(key, func(release func()) { granted <- release })
+	if waiting == nil {
+		return release, nil
+	}
+
+	select {
+	case release := <-granted:
+		return release, nil
+	case <-ctx.Done():
+		err = ErrBusy
+	case <-stop:
+		err = ErrStopped
+	}
+	if !h.leave(key, waiting) {
+		// The slot was passed on meanwhile; pass it along.
+		(<-granted)()
+	}
+	return nil, err
+}
+
+// releaser returns the function giving up a slot held for key.
+func (h *keyLimiter) releaser(key string, slot *keySlot) func() {
+	return func() {
+		h.mu.Lock()
+		if len(slot.waiting) == 0 {
+			if slot.held--; slot.held == 0 {
+				delete(h.slots, key)
+			}
+			h.mu.Unlock()
+			return
+		}
+		next := slot.waiting[0]
+		slot.waiting = slices.Delete(slot.waiting, 0, 1)
+		h.mu.Unlock()
+		next.granted(h.releaser(key, slot))
+	}
+}
+
+// leave takes a waiter out of line. It reports false if the slot has
+// already been passed to it.
+func (h *keyLimiter) leave(key string, waiting *keyWaiter) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	slot := h.slots[key]
+	if slot == nil {
+		return false
+	}
+	i := slices.Index(slot.waiting, waiting)
+	if i < 0 {
+		return false
+	}
+	slot.waiting = slices.Delete(slot.waiting, i, i+1)
+	return true
+}
@@ -0,0 +1,122 @@
+package monitor
+
+import (
+	"container/heap"
+	"math/rand/v2"
+	"time"
+
+	"go-sentinel/internal/models"
+)
+
+// maxJitter caps the random offset added when a monitor first joins the
+// schedule.
+const maxJitter = 30 * time.Second
+
+// entry is a monitor waiting in the schedule. lastRun is the time its most
+// recent check was due, so the next run is computed from the plan rather
+// than from when the check happened to finish.
+type entry struct {
+	monitor models.Monitor
+	next    time.Time
+	lastRun time.Time
+	running bool
+	index   int
+}
+
+// schedule is a min-heap of monitors ordered by next run time, indexed by
+// monitor ID so updates and removals are O(log n).
+type schedule struct {
+	items []*entry
+	byID  map[int64]*entry
+}
+
+func newSchedule() *schedule {
+	return &schedule{byID: make(map[int64]*entry)}
+}
+
+func (s *schedule) Len() int           { return len(s.items) }
+func (s *schedule) Less(i, j int) bool { return s.items[i].next.Before(s.items[j].next) }
+
+func (s *schedule) Swap(i, j int) {
+	s.items[i], s.items[j] = s.items[j], s.items[i]
+	s.items[i].index = i
+	s.items[j].index = j
+}
+
+func (s *schedule) Push(x any) {
+	e := x.(*entry)
+	e.index = len(s.items)
+	s.items = append(s.items, e)
+}
+
+func (s *schedule) Pop() any {
+	last := len(s.items) - 1
+	e := s.items[last]
+	s.items[last] = nil
+	s.items = s.items[:last]
+	return e
+}
+
+func (s *schedule) get(id int64) *entry {
+	return s.byID[id]
+}
+
+// add inserts a monitor that is not yet scheduled.
+func (s *schedule) add(m models.Monitor, next time.Time) {
+	e := &entry{monitor: m, next: next}
+	s.byID[m.ID] = e
+	heap.Push(s, e)
+}
+
+// move changes when an already scheduled monitor runs next.
+func (s *schedule) move(e *entry, next time.Time) {
+	e.next = next
+	heap.Fix(s, e.index)
+}
+
+func (s *schedule) remove(id int64) {
+	if e, ok := s.byID[id]; ok {
+		heap.Remove(s, e.index)
+		delete(s.byID, id)
+	}
+}
+
+// peek returns the monitor that is due first, or nil when nothing is
+// scheduled.
+func (s *schedule) peek() *entry {
+	if len(s.items) == 0 {
+		return nil
+	}
+	return s.items[0]
+}
+
+// nextInterval returns how long to wait between checks. Monitors that are
+// failing use their retry interval when one is set.
+func nextInterval(m models.Monitor, state monitorState) time.Duration {
+	interval := m.Interval
+	if state.failures > 0 && m.RetryInterval > 0 {
+		interval = m.RetryInterval
+	}
+	return time.Duration(interval) * time.Second
+}
+
+// firstRun picks when a monitor joining the schedule runs. Monitors checked
+// recently keep their phase; the rest start after a random offset of up to
+// a tenth of their interval so a restart does not fire everything at once.
+func firstRun(m models.Monitor, now time.Time) time.Time {
+	interval := time.Duration(m.Interval) * time.Second
+	if m.LastCheckedAt != nil {
+		if next := m.LastCheckedAt.Add(interval); next.After(now) {
+			return next
+		}
+	}
+	return now.Add(jitter(interval))
+}
+
+func jitter(interval time.Duration) time.Duration {
+	spread := min(interval/10, maxJitter)
+	if spread <= 0 {
+		return 0
+	}
+	return rand.N(spread)
+}
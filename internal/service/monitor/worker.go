@@ -3,6 +3,8 @@ package monitor
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"go-sentinel/internal/db"
 	"go-sentinel/internal/models"
 	"go-sentinel/internal/service/checker"
@@ -12,84 +14,579 @@ import (
 	"time"
 )
 
+// idleWait is how long the worker sleeps when no monitors are scheduled.
+// Events wake it earlier.
+const idleWait = time.Hour
+
+// retryInterval is how often failed notifications are checked for a due
+// retry.
+const retryInterval = 5 * time.Second
+
+// unwindWait is how long Stop waits for cancelled checks to return.
+const unwindWait = time.Second
+
+type EventKind int
+
 const (
-	workerTickInterval = 30 * time.Second
+	EventCreated EventKind = iota
+	EventUpdated
+	EventDeleted
 )
 
-func StartWorker(ctx context.Context, database *sql.DB) {
-	ticker := time.NewTicker(workerTickInterval)
-	cleanupTicker := time.NewTicker(1 * time.Hour)
-	var monitorState sync.Map
+// Event tells the worker that a monitor changed so its schedule can be
+// updated without polling the database.
+type Event struct {
+	Kind      EventKind
+	MonitorID int64
+}
+
+// monitorState tracks the confirmed up/down state of a monitor, since when
+// it has held, and how many checks in a row have failed. It is persisted on
+// the monitor row so restarts do not lose it.
+type monitorState struct {
+	known    bool
+	isUp     bool
+	since    time.Time
+	failures int
+	silent   bool // down because of its parent, and not announced
+	flapping bool
+	changes  flapHistory
+}
+
+// savedState restores the state persisted on a monitor row.
+func savedState(m models.Monitor) monitorState {
+	state := monitorState{failures: m.ConsecutiveFailures, flapping: m.Flapping}
+	if m.Status != "" {
+		state.known = true
+		state.isUp = m.Status == models.StatusUp
+	}
+	if m.StatusSince != nil {
+		state.since = *m.StatusSince
+	}
+	return state
+}
+
+func (s monitorState) status() string {
+	switch {
+	case !s.known:
+		return ""
+	case s.isUp:
+		return models.StatusUp
+	default:
+		return models.StatusDown
+	}
+}
+
+// certWarnDays are the days-left thresholds at which a certificate expiry
+// warning is sent. Each threshold fires at most once per certificate.
+var certWarnDays = []int{14, 7, 3, 1}
+
+// finishedCheck reports a completed check back to the scheduling loop.
+type finishedCheck struct {
+	monitorID int64
+	state     monitorState
+}
+
+// Worker runs monitor checks on schedule. Only the goroutine started by
+// StartWorker touches the schedule; due checks go through a bounded queue
+// to a fixed pool of goroutines that report back over channels.
+type Worker struct {
+	db       *sql.DB
+	cfg      Config
+	events   chan Event
+	jobs     chan job
+	finished chan finishedCheck
+	done     chan struct{}
+	states   sync.Map
+	queue    *schedule
+	hosts    *keyLimiter // checks running per target host
+	checking *keyLimiter // one check at a time per monitor ID
+	counters poolCounters
 
+	// ready holds jobs whose slots came free while they waited in line;
+	// pool workers take them before new jobs. wake signals that it is not
+	// empty.
+	readyMu sync.Mutex
+	ready   []job
+	wake    chan struct{}
+
+	// stopping is closed by Stop to end scheduling; ctx is only cancelled
+	// once in-flight checks have had their chance to finish.
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stopping chan struct{}
+	stopOnce sync.Once
+	workers  sync.WaitGroup
+}
+
+// StartWorker starts scheduling checks. The worker runs until Stop is
+// called.
+func StartWorker(database *sql.DB, cfg Config) *Worker {
+	cfg = cfg.withDefaults()
+	ctx, cancel := context.WithCancel(context.Background())
+	w := &Worker{
+		db:       database,
+		cfg:      cfg,
+		events:   make(chan Event, 64),
+		jobs:     make(chan job, cfg.QueueSize),
+		finished: make(chan finishedCheck, cfg.Workers),
+		done:     make(chan struct{}),
+		queue:    newSchedule(),
+		hosts:    newKeyLimiter(cfg.PerHostLimit),
+		checking: newKeyLimiter(1),
+		wake:     make(chan struct{}, 1),
+		ctx:      ctx,
+		cancel:   cancel,
+		stopping: make(chan struct{}),
+	}
+	w.workers.Add(cfg.Workers)
+	for range cfg.Workers {
+		go w.work(ctx)
+	}
+	go w.run(ctx)
+	return w
+}
+
+// Stop stops scheduling new checks, drops queued ones, and waits up to
+// timeout for running checks and the notifications they trigger. Checks
+// still running at the deadline are cancelled and given up to unwindWait
+// to return; only a check stuck past that may still touch the database
+// after Stop returns.
+func (w *Worker) Stop(timeout time.Duration) error {
+	if w == nil {
+		return nil
+	}
+	w.stopOnce.Do(func() { close(w.stopping) })
+	<-w.done
+
+	deadline, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	idle := make(chan struct{})
 	go func() {
-		defer ticker.Stop()
-		defer cleanupTicker.Stop()
-
-		for {
-			select {
-			case <-ctx.Done():
-				log.Println("Worker shutdown complete")
-				return
-
-			case <-ticker.C:
-				targets, err := db.GetMonitors(ctx, database)
-				if err != nil {
-					log.Printf("Worker error: failed to fetch monitors: %v", err)
-					continue
-				}
-
-				dueCount := 0
-				for _, target := range targets {
-					if isDue(target) {
-						dueCount++
-						if err := db.UpdateLastChecked(ctx, database, target.ID); err != nil {
-							log.Printf("Worker error: failed to update timestamp: %v", err)
-							continue
-						}
-
-						go func(t models.Monitor) {
-							result := checker.PerformHTTPCheck(ctx, t.URL)
-
-							check := models.Check{
-								MonitorID:  t.ID,
-								StatusCode: result.StatusCode,
-								Latency:    result.Latency,
-								IsUp:       result.IsUp,
-							}
-
-							if err := db.SaveCheckAndUpdateStats(ctx, database, check); err != nil {
-								log.Printf("Worker error: failed to save check for %s: %v", t.Name, err)
-							}
-
-							if prev, known := monitorState.Load(t.ID); known && prev.(bool) != result.IsUp {
-								notifier.NotifyStateChange(ctx, database, t, check)
-							}
-							monitorState.Store(t.ID, result.IsUp)
-						}(target)
-					}
-				}
-
-				if dueCount > 0 {
-					log.Printf("Worker: processed %d due monitors", dueCount)
-				}
-
-			case <-cleanupTicker.C:
-				rows, err := db.CleanupOldChecks(ctx, database, 7)
-				if err != nil {
-					log.Printf("Cleanup error: %v", err)
-				} else if rows > 0 {
-					log.Printf("Cleanup: removed %d old check records", rows)
-				}
+		w.workers.Wait()
+		close(idle)
+	}()
+
+	var err error
+	select {
+	case <-idle:
+		err = notifier.Wait(deadline)
+	case <-deadline.Done():
+		err = errors.New("timed out waiting for running checks")
+	}
+
+	w.cancel()
+	if err != nil {
+		// Cancelled checks fail to save, so no false failure is recorded.
+		// Let them unwind before the caller closes the database.
+		select {
+		case <-idle:
+		case <-time.After(unwindWait):
+			err = errors.New("timed out waiting for cancelled checks")
+		}
+		return err
+	}
+	log.Println("Worker shutdown complete")
+	return nil
+}
+
+// Notify queues a monitor change for the worker. It is safe to call on a
+// nil Worker and after the worker has stopped.
+func (w *Worker) Notify(ev Event) {
+	if w == nil {
+		return
+	}
+	select {
+	case w.events <- ev:
+	case <-w.done:
+	}
+}
+
+func (w *Worker) run(ctx context.Context) {
+	defer close(w.done)
+
+	cleanupTicker := time.NewTicker(1 * time.Hour)
+	defer cleanupTicker.Stop()
+	retryTicker := time.NewTicker(retryInterval)
+	defer retryTicker.Stop()
+
+	w.resync(ctx)
+	notifier.RetryDue(ctx, w.db, time.Now())
+	timer := time.NewTimer(w.untilNext())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-w.stopping:
+			return
+
+		case ev := <-w.events:
+			w.apply(ctx, ev)
+
+		case f := <-w.finished:
+			w.reschedule(f)
+
+		case <-timer.C:
+			w.dispatchDue(ctx)
+
+		case <-cleanupTicker.C:
+			rows, err := db.CleanupOldChecks(ctx, w.db, 7)
+			if err != nil {
+				log.Printf("Cleanup error: %v", err)
+			} else if rows > 0 {
+				log.Printf("Cleanup: removed %d old check records", rows)
+			}
+			rows, err = db.CleanupOldDeliveries(ctx, w.db, 7)
+			if err != nil {
+				log.Printf("Cleanup error: %v", err)
+			} else if rows > 0 {
+				log.Printf("Cleanup: removed %d old notification deliveries", rows)
 			}
+			w.resync(ctx)
+
+		case <-retryTicker.C:
+			notifier.RetryDue(ctx, w.db, time.Now())
 		}
-	}()
+
+		timer.Reset(w.untilNext())
+	}
+}
+
+func (w *Worker) untilNext() time.Duration {
+	head := w.queue.peek()
+	if head == nil {
+		return idleWait
+	}
+	return max(time.Until(head.next), 0)
+}
+
+// resync reconciles the schedule with the database, picking up monitors
+// whose events were missed. Existing entries keep their next run time.
+func (w *Worker) resync(ctx context.Context) {
+	monitors, err := db.GetMonitors(ctx, w.db)
+	if err != nil {
+		log.Printf("Worker error: failed to fetch monitors: %v", err)
+		return
+	}
+
+	now := time.Now()
+	seen := make(map[int64]bool, len(monitors))
+	for _, m := range monitors {
+		if !m.Active {
+			continue
+		}
+		seen[m.ID] = true
+		if e := w.queue.get(m.ID); e != nil {
+			e.monitor = m
+			continue
+		}
+		w.track(m, firstRun(m, now))
+	}
+	for id := range w.queue.byID {
+		if !seen[id] {
+			w.forget(id)
+		}
+	}
+}
+
+func (w *Worker) apply(ctx context.Context, ev Event) {
+	if ev.Kind == EventDeleted {
+		w.forget(ev.MonitorID)
+		return
+	}
+
+	m, err := db.GetMonitor(ctx, w.db, ev.MonitorID)
+	if err != nil {
+		log.Printf("Worker error: failed to load monitor %d: %v", ev.MonitorID, err)
+		return
+	}
+	if m == nil || !m.Active {
+		w.forget(ev.MonitorID)
+		return
+	}
+
+	e := w.queue.get(m.ID)
+	if e == nil {
+		w.track(*m, firstRun(*m, time.Now()))
+		return
+	}
+	e.monitor = *m
+	if !e.running && !e.lastRun.IsZero() {
+		w.queue.move(e, e.lastRun.Add(nextInterval(*m, loadState(&w.states, m.ID))))
+	}
 }
 
-func isDue(m models.Monitor) bool {
-	if m.LastCheckedAt == nil {
+// track schedules a monitor that is new to the worker, picking up its
+// persisted state. Paused monitors are left out of the schedule entirely
+// and rejoin through track when resumed.
+func (w *Worker) track(m models.Monitor, next time.Time) {
+	w.states.LoadOrStore(m.ID, savedState(m))
+	w.queue.add(m, next)
+}
+
+func (w *Worker) forget(id int64) {
+	w.queue.remove(id)
+	w.states.Delete(id)
+}
+
+// dispatchDue queues every check whose time has come. A monitor whose
+// previous check is still queued or running skips this round rather than
+// running twice at once; the skipped round counts as dropped.
+func (w *Worker) dispatchDue(ctx context.Context) {
+	now := time.Now()
+	for e := w.queue.peek(); e != nil && !e.next.After(now); e = w.queue.peek() {
+		due := e.next
+		interval := nextInterval(e.monitor, loadState(&w.states, e.monitor.ID))
+		if e.running {
+			w.counters.dropped.Add(1)
+			w.queue.move(e, due.Add(interval))
+			continue
+		}
+
+		// Reload so the check sees settings and heartbeats written since the
+		// monitor was scheduled.
+		m, err := db.GetMonitor(ctx, w.db, e.monitor.ID)
+		if err != nil {
+			log.Printf("Worker error: failed to load monitor %s: %v", e.monitor.Name, err)
+			w.queue.move(e, now.Add(interval))
+			continue
+		}
+		if m == nil || !m.Active {
+			w.forget(e.monitor.ID)
+			continue
+		}
+
+		e.monitor = *m
+		e.lastRun = due
+		w.queue.move(e, due.Add(interval))
+		if !w.submit(job{monitor: *m, due: due}) {
+			log.Printf("Worker: check queue full, dropped check for %s", m.Name)
+			continue
+		}
+		e.running = true
+
+		if err := db.UpdateLastChecked(ctx, w.db, m.ID); err != nil {
+			log.Printf("Worker error: failed to update timestamp: %v", err)
+		}
+	}
+}
+
+// reschedule plans the next run once a check completes, since its result
+// decides whether the retry interval applies. Runs are spaced from when the
+// check was due so intervals do not drift.
+func (w *Worker) reschedule(f finishedCheck) {
+	e := w.queue.get(f.monitorID)
+	if e == nil {
+		return
+	}
+	e.running = false
+	next := e.lastRun.Add(nextInterval(e.monitor, f.state))
+	if now := time.Now(); next.Before(now) {
+		next = now
+	}
+	w.queue.move(e, next)
+}
+
+func loadState(states *sync.Map, id int64) monitorState {
+	if v, ok := states.Load(id); ok {
+		return v.(monitorState)
+	}
+	return monitorState{}
+}
+
+// checkRun is everything a check produced.
+type checkRun struct {
+	check       models.Check
+	certificate *models.Certificate
+	state       monitorState
+}
+
+func newCheck(m models.Monitor, result checker.CheckResult) models.Check {
+	return models.Check{
+		MonitorID:  m.ID,
+		StatusCode: result.StatusCode,
+		Latency:    result.Latency,
+		Timings:    result.Timings,
+		IsUp:       result.IsUp,
+		Assertion:  result.Assertion,
+
+		ErrorCategory: result.ErrorCategory,
+		ErrorMessage:  result.ErrorMessage,
+		CheckedAt:     time.Now(),
+	}
+}
+
+// runCheck performs a check, saves it, updates the monitor's state and
+// sends any resulting notification.
+func runCheck(ctx context.Context, database *sql.DB, states *sync.Map, t models.Monitor) checkRun {
+	result := checker.Perform(ctx, t)
+	check := newCheck(t, result)
+	check.Maintenance = inMaintenance(ctx, database, t)
+
+	parentDown := false
+	if !check.IsUp && t.ParentID != 0 {
+		if parent := downParent(ctx, database, states, t); parent != "" {
+			parentDown = true
+			check.ErrorCategory = models.ErrorDependency
+			check.ErrorMessage = fmt.Sprintf("dependency %s is down; %s", parent, check.ErrorMessage)
+		}
+	}
+
+	if err := db.SaveCheckAndUpdateStats(ctx, database, check); err != nil {
+		log.Printf("Worker error: failed to save check for %s: %v", t.Name, err)
+	}
+
+	if result.Certificate != nil {
+		checkCertificate(ctx, database, t, *result.Certificate)
+	}
+
+	// The confirmed state is frozen during maintenance, so a monitor that
+	// is still down when the window ends alerts then.
+	prev := loadState(states, t.ID)
+	if check.Maintenance {
+		return checkRun{check, result.Certificate, prev}
+	}
+	now := time.Now()
+	next, changed := nextState(prev, result.IsUp, t.ConfirmAfterChecks(), now)
+	notify := applyDependency(prev, &next, changed, parentDown)
+	flap := applyFlapping(&next, changed && !parentDown, now)
+	states.Store(t.ID, next)
+	if next != prev {
+		if err := db.UpdateMonitorStatus(ctx, database, t.ID, next.status(), next.since, next.failures, next.flapping); err != nil {
+			log.Printf("Worker error: failed to save status for %s: %v", t.Name, err)
+		}
+	}
+	switch {
+	case flap != flapUnchanged:
+		notifier.NotifyFlapping(ctx, database, t, check, flap == flapStarted)
+	case notify && !next.flapping:
+		notifier.NotifyStateChange(ctx, database, t, check, prev.status(), prev.since, dependentNames(ctx, database, t))
+	}
+	return checkRun{check, result.Certificate, next}
+}
+
+// downParent returns the name of the monitor's parent if the worker has it
+// confirmed down, or "" otherwise.
+func downParent(ctx context.Context, database *sql.DB, states *sync.Map, m models.Monitor) string {
+	state := loadState(states, m.ParentID)
+	if !state.known || state.isUp {
+		return ""
+	}
+	parent, err := db.GetMonitor(ctx, database, m.ParentID)
+	if err != nil || parent == nil {
+		return ""
+	}
+	return parent.Name
+}
+
+// applyDependency decides whether a check is announced. Outages caused by
+// a down parent are kept silent, and so is their recovery; the parent's own
+// alert lists them instead. If the monitor is still down once its parent
+// is back, the outage is announced then.
+func applyDependency(prev monitorState, next *monitorState, changed, parentDown bool) bool {
+	switch {
+	case changed && !next.isUp && parentDown:
+		next.silent = true
+		return false
+	case changed && next.isUp:
+		next.silent = false
+		return !prev.silent
+	case !changed && next.silent && !next.isUp && !parentDown:
+		next.silent = false
 		return true
 	}
+	return changed
+}
+
+// dependentNames lists the monitors depending on m, for the grouped alert
+// sent when m changes state.
+func dependentNames(ctx context.Context, database *sql.DB, m models.Monitor) []string {
+	dependents, err := db.GetDependentMonitors(ctx, database, m.ID)
+	if err != nil {
+		log.Printf("Worker error: failed to load dependents of %s: %v", m.Name, err)
+		return nil
+	}
+	names := make([]string, 0, len(dependents))
+	for _, d := range dependents {
+		names = append(names, d.Name)
+	}
+	return names
+}
+
+// inMaintenance reports whether any maintenance window linked to the
+// monitor is active now.
+func inMaintenance(ctx context.Context, database *sql.DB, m models.Monitor) bool {
+	windows, err := db.GetMaintenanceWindowsForMonitor(ctx, database, m.ID)
+	if err != nil {
+		log.Printf("Worker error: failed to load maintenance windows for %s: %v", m.Name, err)
+		return false
+	}
+	now := time.Now()
+	for _, mw := range windows {
+		if mw.ActiveAt(now) {
+			return true
+		}
+	}
+	return false
+}
 
-	nextCheck := m.LastCheckedAt.Add(time.Duration(m.Interval) * time.Second)
-	return time.Now().After(nextCheck)
+// nextState applies a check result. A monitor only flips to down after
+// confirmAfter consecutive failures; a single success brings it back up.
+// The first confirmed state of a new monitor is recorded without a
+// transition.
+func nextState(prev monitorState, isUp bool, confirmAfter int, now time.Time) (monitorState, bool) {
+	next := prev
+	if isUp {
+		next.failures = 0
+	} else {
+		next.failures++
+		if next.failures < confirmAfter {
+			return next, false
+		}
+	}
+
+	next.isUp = isUp
+	next.known = true
+	if !prev.known || prev.isUp != isUp {
+		next.since = now
+	}
+	return next, prev.known && prev.isUp != isUp
+}
+
+func checkCertificate(ctx context.Context, database *sql.DB, m models.Monitor, cert models.Certificate) {
+	if err := db.SaveCertificate(ctx, database, cert); err != nil {
+		log.Printf("Worker error: failed to save certificate for %s: %v", m.Name, err)
+		return
+	}
+
+	daysLeft := cert.DaysLeft(time.Now())
+	if daysLeft < 0 {
+		return
+	}
+
+	threshold := 0
+	for _, d := range certWarnDays {
+		if daysLeft <= d {
+			threshold = d
+		}
+	}
+	if threshold == 0 {
+		return
+	}
+
+	warned, err := db.GetCertificateWarnedDays(ctx, database, m.ID)
+	if err != nil {
+		log.Printf("Worker error: failed to load certificate state for %s: %v", m.Name, err)
+		return
+	}
+	if warned != 0 && warned <= threshold {
+		return
+	}
+
+	if err := db.SetCertificateWarnedDays(ctx, database, m.ID, threshold); err != nil {
+		log.Printf("Worker error: failed to update certificate state for %s: %v", m.Name, err)
+		return
+	}
+	notifier.NotifyCertificateExpiry(ctx, database, m, cert, daysLeft)
 }
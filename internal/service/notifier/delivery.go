@@ -0,0 +1,244 @@
+package notifier
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"go-sentinel/internal/db"
+	"go-sentinel/internal/models"
+	"log"
+	"net"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	// maxAttempts is how often a delivery is tried before it is given up.
+	maxAttempts = 8
+
+	// Retries back off exponentially from retryBase up to retryMax. A
+	// Retry-After from the service wins if it is longer, up to
+	// maxRetryAfter.
+	retryBase     = 10 * time.Second
+	retryMax      = 15 * time.Minute
+	maxRetryAfter = time.Hour
+
+	// deliveryLease is how long an attempt holds a delivery. If the
+	// process dies mid-attempt the delivery becomes due again afterwards.
+	deliveryLease = 2 * time.Minute
+
+	// retryBatch caps the deliveries RetryDue starts at once.
+	retryBatch = 50
+)
+
+// supersededEvents lists the events that an event of the given kind makes
+// stale: a newer state change replaces any older one not yet delivered, and
+// a newer expiry warning an older warning.
+func supersededEvents(kind EventKind) []string {
+	if kind == EventCertificateExpiry {
+		return []string{string(EventCertificateExpiry)}
+	}
+	return []string{string(EventDown), string(EventRecovery), string(EventFlapping), string(EventStabilised)}
+}
+
+// storedEvent is an Event as kept in the outbox. The check's status code is
+// not part of its JSON, so it is carried alongside.
+type storedEvent struct {
+	Event
+	StatusCode int
+}
+
+func encodeEvent(ev Event) (string, error) {
+	b, err := json.Marshal(storedEvent{Event: ev, StatusCode: ev.Check.StatusCode})
+	return string(b), err
+}
+
+func decodeEvent(payload string) (Event, error) {
+	var stored storedEvent
+	if err := json.Unmarshal([]byte(payload), &stored); err != nil {
+		return Event{}, err
+	}
+	ev := stored.Event
+	ev.Check.StatusCode = stored.StatusCode
+	return ev, nil
+}
+
+// RetryDue attempts the pending deliveries whose retry is due at now in the
+// background.
+func RetryDue(ctx context.Context, database *sql.DB, now time.Time) {
+	due, err := db.GetDueDeliveries(ctx, database, now, retryBatch)
+	if err != nil {
+		log.Printf("Notifier: failed to fetch due deliveries: %v", err)
+		return
+	}
+
+	for _, d := range due {
+		claimed, err := db.ClaimDelivery(ctx, database, d.ID, now, now.Add(deliveryLease))
+		if err != nil {
+			log.Printf("Notifier: failed to claim delivery %d: %v", d.ID, err)
+			continue
+		}
+		if !claimed {
+			continue // picked up elsewhere
+		}
+
+		wh, err := db.GetWebhook(ctx, database, d.WebhookID)
+		if err != nil {
+			log.Printf("Notifier: failed to fetch webhook %d: %v", d.WebhookID, err)
+			continue
+		}
+		if wh == nil {
+			continue // deleted along with its deliveries
+		}
+		if !wh.Enabled {
+			giveUp(ctx, database, d, "channel disabled")
+			continue
+		}
+		ev, err := decodeEvent(d.Payload)
+		if err != nil {
+			giveUp(ctx, database, d, "invalid payload: "+err.Error())
+			continue
+		}
+
+		pending.Add(1)
+		go func() {
+			defer pending.Done()
+			attempt(ctx, database, d, *wh, ev)
+		}()
+	}
+}
+
+// giveUp fails a delivery without sending it.
+func giveUp(ctx context.Context, database *sql.DB, d models.Delivery, reason string) {
+	a := models.DeliveryAttempt{AttemptedAt: time.Now(), Error: reason}
+	if err := db.RecordDeliveryAttempt(ctx, database, d.ID, a, models.DeliveryFailed, time.Time{}); err != nil {
+		log.Printf("Notifier: failed to record delivery %d: %v", d.ID, err)
+	}
+}
+
+// attempt sends a delivery once and records the outcome. A delivery with
+// no ID was never queued and is not recorded.
+func attempt(ctx context.Context, database *sql.DB, d models.Delivery, wh models.Webhook, ev Event) {
+	n, ok := notifiers[wh.Type]
+	if !ok {
+		giveUp(ctx, database, d, "unknown channel type "+wh.Type)
+		return
+	}
+
+	info := &attemptInfo{}
+	start := time.Now()
+	err := n.Send(context.WithValue(ctx, attemptKey{}, info), wh, ev)
+	if err != nil && ctx.Err() != nil {
+		// Shutting down: leave the delivery leased so that it is retried
+		// after a restart.
+		log.Printf("Notifier: %s webhook %s interrupted: %v", wh.Type, wh.Name, ctx.Err())
+		return
+	}
+	if d.ID == 0 {
+		if err != nil {
+			log.Printf("Notifier: failed to send %s webhook %s: %v", wh.Type, wh.Name, err)
+		}
+		return
+	}
+
+	a := models.DeliveryAttempt{
+		AttemptedAt: start,
+		StatusCode:  info.statusCode,
+		Duration:    time.Since(start).Milliseconds(),
+	}
+	status, next := models.DeliveryDelivered, time.Time{}
+	if err != nil {
+		a.Error = err.Error()
+		attempts := d.AttemptCount + 1
+		if retryable(err) && attempts < maxAttempts {
+			status, next = models.DeliveryPending, time.Now().Add(retryDelay(attempts, err))
+			log.Printf("Notifier: failed to send %s webhook %s, retrying at %s: %v",
+				wh.Type, wh.Name, next.Format(time.RFC3339), err)
+		} else if attempts >= maxAttempts {
+			status = models.DeliveryFailed
+			log.Printf("Notifier: giving up on %s webhook %s after %d attempts: %v", wh.Type, wh.Name, attempts, err)
+		} else {
+			status = models.DeliveryFailed
+			log.Printf("Notifier: failed to send %s webhook %s: %v", wh.Type, wh.Name, err)
+		}
+	}
+	// Record even if shutdown began after the send finished, so that it is
+	// not sent twice.
+	if err := db.RecordDeliveryAttempt(context.WithoutCancel(ctx), database, d.ID, a, status, next); err != nil {
+		log.Printf("Notifier: failed to record delivery %d: %v", d.ID, err)
+	}
+}
+
+// attemptInfo collects what deliver saw during one attempt.
+type attemptInfo struct {
+	statusCode int
+}
+
+type attemptKey struct{}
+
+// recordStatus notes the response status for the attempt in ctx, if any.
+func recordStatus(ctx context.Context, code int) {
+	if info, ok := ctx.Value(attemptKey{}).(*attemptInfo); ok {
+		info.statusCode = code
+	}
+}
+
+// retryable reports whether a failed send may succeed later: rate limits,
+// server errors, temporary SMTP failures and network errors.
+func retryable(err error) bool {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code == http.StatusTooManyRequests || statusErr.Code >= 500
+	}
+	var smtpErr *textproto.Error
+	if errors.As(err, &smtpErr) {
+		return smtpErr.Code >= 400 && smtpErr.Code < 500
+	}
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) && urlErr.Op == "parse" {
+		return false // a bad channel URL stays bad
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// retryDelay is the wait before the next try after attempts failed
+// attempts.
+func retryDelay(attempts int, err error) time.Duration {
+	delay := retryBase
+	for i := 1; i < attempts && delay < retryMax; i++ {
+		delay *= 2
+	}
+	delay = min(delay, retryMax)
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		delay = max(delay, min(statusErr.RetryAfter, maxRetryAfter))
+	}
+	return delay
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP
+// date. It returns zero if the header is missing or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(value, 64); err == nil {
+		if secs <= 0 {
+			return 0
+		}
+		if secs > maxRetryAfter.Seconds() {
+			return maxRetryAfter
+		}
+		return time.Duration(secs * float64(time.Second))
+	}
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,180 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"go-sentinel/internal/models"
+	"html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// smtpTimeout bounds a whole SMTP conversation.
+const smtpTimeout = 30 * time.Second
+
+// emailNotifier sends a multipart message with plain-text and HTML bodies
+// over SMTP. STARTTLS is required unless the channel's starttls setting is
+// "false", and credentials are never sent over an unencrypted connection
+// to a remote host.
+type emailNotifier struct{}
+
+func (emailNotifier) Send(ctx context.Context, wh models.Webhook, ev Event) error {
+	s := wh.Settings
+	recipients, err := models.EmailRecipients(s["to"])
+	if err != nil {
+		return err
+	}
+	from, err := mail.ParseAddress(s["from"])
+	if err != nil {
+		return fmt.Errorf("invalid from address: %w", err)
+	}
+	body, err := buildEmail(buildMessage(ev), from.String(), recipients, ev.Time)
+	if err != nil {
+		return err
+	}
+
+	port := s["port"]
+	if port == "" {
+		port = "587"
+	}
+	ctx, cancel := context.WithTimeout(ctx, smtpTimeout)
+	defer cancel()
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", net.JoinHostPort(s["host"], port))
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	c, err := smtp.NewClient(conn, s["host"])
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	// Channels saved before starttls was normalised may hold any ParseBool
+	// spelling; anything unreadable keeps STARTTLS on.
+	if starttls, err := strconv.ParseBool(s["starttls"]); err != nil || starttls {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return errors.New("server does not support STARTTLS")
+		}
+		if err := c.StartTLS(&tls.Config{ServerName: s["host"]}); err != nil {
+			return fmt.Errorf("starttls: %w", err)
+		}
+	}
+	if s["username"] != "" {
+		if err := c.Auth(smtp.PlainAuth("", s["username"], s["password"], s["host"])); err != nil {
+			return fmt.Errorf("auth: %w", err)
+		}
+	}
+
+	if err := c.Mail(from.Address); err != nil {
+		return err
+	}
+	for _, to := range recipients {
+		if err := c.Rcpt(to); err != nil {
+			return fmt.Errorf("recipient %s: %w", to, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+var emailTemplate = template.Must(template.New("email").Parse(`<!DOCTYPE html>
+<html>
+<body style="margin:0;padding:24px;background:#f4f4f5;font-family:-apple-system,Segoe UI,Helvetica,Arial,sans-serif;color:#18181b">
+<table role="presentation" width="100%" style="max-width:600px;margin:0 auto;background:#ffffff;border-radius:8px;border-top:4px solid {{.Color}}">
+<tr><td style="padding:24px">
+<h2 style="margin:0 0 12px;font-size:18px">{{.Title}}</h2>
+<p style="margin:0 0 16px;font-size:14px;line-height:1.5">{{.Description}}</p>
+<table role="presentation" width="100%" style="font-size:13px;border-collapse:collapse">
+{{- range .Fields}}
+<tr><td style="padding:6px 12px 6px 0;color:#71717a;white-space:nowrap;vertical-align:top">{{.Name}}</td><td style="padding:6px 0;word-break:break-all">{{.Value}}</td></tr>
+{{- end}}
+</table>
+<p style="margin:16px 0 0;font-size:12px;color:#a1a1aa">go-sentinel · {{.Time}}</p>
+</td></tr>
+</table>
+</body>
+</html>
+`))
+
+// buildEmail renders the full message, headers included, as a
+// multipart/alternative email.
+func buildEmail(msg message, from string, to []string, at time.Time) ([]byte, error) {
+	var htmlBody bytes.Buffer
+	err := emailTemplate.Execute(&htmlBody, map[string]any{
+		"Title":       msg.Title,
+		"Description": template.HTML(replaceBold(msg.Description, "<strong>", "</strong>", template.HTMLEscapeString)),
+		"Color":       fmt.Sprintf("#%06X", msg.Color),
+		"Fields":      msg.Fields,
+		"Time":        at.UTC().Format("2006-01-02 15:04 UTC"),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var text strings.Builder
+	text.WriteString(msg.Title + "\n\n" + strings.ReplaceAll(msg.Description, "**", "") + "\n\n")
+	for _, f := range msg.Fields {
+		text.WriteString(f.Name + ": " + f.Value + "\n")
+	}
+	text.WriteString("\n-- \ngo-sentinel · " + at.UTC().Format("2006-01-02 15:04 UTC") + "\n")
+
+	var buf bytes.Buffer
+	parts := multipart.NewWriter(&buf)
+	headers := []string{
+		"From: " + from,
+		"To: " + strings.Join(to, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", msg.Title),
+		"Date: " + at.Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + strconv.Quote(parts.Boundary()),
+	}
+	buf.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", text.String()},
+		{"text/html; charset=utf-8", htmlBody.String()},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
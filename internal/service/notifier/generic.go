@@ -0,0 +1,119 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-sentinel/internal/models"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateData is what a generic webhook's body template is executed with,
+// and, without a template, the JSON body that is sent.
+type TemplateData struct {
+	Event          EventKind           `json:"event"`
+	Title          string              `json:"title"`
+	Message        string              `json:"message"`
+	Monitor        models.Monitor      `json:"monitor"`
+	Check          models.Check        `json:"check"`
+	Certificate    *models.Certificate `json:"certificate,omitempty"`
+	DaysLeft       int                 `json:"days_left,omitempty"`
+	PreviousState  string              `json:"previous_state"`
+	DownFor        time.Duration       `json:"-"`
+	DownForSeconds int64               `json:"down_for_seconds"`
+	Dependents     []string            `json:"dependents,omitempty"`
+	Time           time.Time           `json:"time"`
+}
+
+var templateFuncs = template.FuncMap{
+	// json encodes a value, so strings can be placed in a JSON body
+	// safely: {"text": {{json .Title}}}.
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// ParseTemplate parses a generic webhook body template. It is used to
+// reject broken templates before they are saved.
+func ParseTemplate(text string) (*template.Template, error) {
+	t, err := template.New("body").Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid body template: %w", err)
+	}
+	return t, nil
+}
+
+// genericNotifier sends the event to any HTTP endpoint. The method setting
+// picks the HTTP method, POST by default, and the body setting is a
+// text/template rendered with TemplateData. Without a body template the
+// TemplateData itself is sent as JSON.
+type genericNotifier struct{}
+
+func (genericNotifier) Send(ctx context.Context, wh models.Webhook, ev Event) error {
+	body, err := renderGeneric(wh, ev)
+	if err != nil {
+		return err
+	}
+
+	method := wh.Settings["method"]
+	if method == "" {
+		method = http.MethodPost
+	}
+	header := make(http.Header, len(wh.Headers)+1)
+	header.Set("Content-Type", "application/json")
+	for name, value := range wh.Headers {
+		header.Set(name, value)
+	}
+	return deliver(ctx, method, wh.URL, header, body)
+}
+
+func renderGeneric(wh models.Webhook, ev Event) ([]byte, error) {
+	data := newTemplateData(ev)
+	text := wh.Settings["body"]
+	if text == "" {
+		return json.Marshal(data)
+	}
+
+	t, err := ParseTemplate(text)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("render body template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// newTemplateData builds the template data for an event. Request headers,
+// body and push token are left out of the monitor, as they may hold
+// credentials for the monitored service.
+func newTemplateData(ev Event) TemplateData {
+	msg := buildMessage(ev)
+	monitor := ev.Monitor
+	monitor.Headers, monitor.Body, monitor.PushToken = nil, "", ""
+	data := TemplateData{
+		Event:          ev.Kind,
+		Title:          msg.Title,
+		Message:        strings.ReplaceAll(msg.Description, "**", ""),
+		Monitor:        monitor,
+		Check:          ev.Check,
+		DaysLeft:       ev.DaysLeft,
+		PreviousState:  ev.PreviousStatus,
+		DownFor:        ev.DownFor(),
+		DownForSeconds: int64(ev.DownFor().Seconds()),
+		Dependents:     ev.Dependents,
+		Time:           ev.Time,
+	}
+	if ev.Kind == EventCertificateExpiry {
+		data.Certificate = &ev.Certificate
+	}
+	return data
+}
@@ -0,0 +1,156 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"go-sentinel/internal/models"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Incident channels open an incident when a monitor goes down and resolve
+// it when the monitor recovers. A flapping monitor keeps its incident open
+// until it settles. Certificate warnings are not sent: nothing would ever
+// resolve them.
+
+// dedupKey identifies a monitor's incident so a resolve closes the
+// incident its trigger opened, and repeated triggers do not open more.
+func dedupKey(m models.Monitor) string {
+	return fmt.Sprintf("go-sentinel-monitor-%d", m.ID)
+}
+
+// incidentAction reports whether the event opens or resolves an incident,
+// and false if incident channels ignore it.
+func incidentAction(ev Event) (trigger bool, ok bool) {
+	switch ev.Kind {
+	case EventDown, EventFlapping:
+		return true, true
+	case EventRecovery:
+		return false, true
+	case EventStabilised:
+		return !ev.Check.IsUp, true
+	}
+	return false, false
+}
+
+// incidentDetails are the message fields as key/value pairs.
+func incidentDetails(msg message) map[string]string {
+	details := make(map[string]string, len(msg.Fields))
+	for _, f := range msg.Fields {
+		details[f.Name] = f.Value
+	}
+	return details
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Timestamp     string            `json:"timestamp"`
+	Component     string            `json:"component,omitempty"`
+	CustomDetails map[string]string `json:"custom_details,omitempty"`
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Client      string            `json:"client,omitempty"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+}
+
+// pagerDutyNotifier sends PagerDuty Events API v2 events to the service
+// named by the routing_key setting.
+type pagerDutyNotifier struct{}
+
+func (pagerDutyNotifier) Send(ctx context.Context, wh models.Webhook, ev Event) error {
+	trigger, ok := incidentAction(ev)
+	if !ok {
+		return nil
+	}
+	return postJSON(ctx, wh.URL, buildPagerDutyEvent(ev, wh.Settings["routing_key"], trigger))
+}
+
+func buildPagerDutyEvent(ev Event, routingKey string, trigger bool) pagerDutyEvent {
+	event := pagerDutyEvent{
+		RoutingKey:  routingKey,
+		EventAction: "resolve",
+		DedupKey:    dedupKey(ev.Monitor),
+		Client:      "go-sentinel",
+	}
+	if !trigger {
+		return event
+	}
+
+	msg := buildMessage(ev)
+	event.EventAction = "trigger"
+	event.Payload = &pagerDutyPayload{
+		Summary:       truncate(msg.Title, 1024),
+		Source:        targetText(ev.Monitor),
+		Severity:      "critical",
+		Timestamp:     ev.Time.UTC().Format(time.RFC3339),
+		Component:     ev.Monitor.Name,
+		CustomDetails: incidentDetails(msg),
+	}
+	return event
+}
+
+type opsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description,omitempty"`
+	Source      string            `json:"source"`
+	Priority    string            `json:"priority"`
+	Entity      string            `json:"entity,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+}
+
+type opsgenieClose struct {
+	Source string `json:"source"`
+	Note   string `json:"note,omitempty"`
+}
+
+// opsgenieNotifier creates and closes Opsgenie alerts through the Alert
+// API, authenticating with the api_key setting. The channel's URL is the
+// API server, so EU accounts can use https://api.eu.opsgenie.com.
+type opsgenieNotifier struct{}
+
+func (opsgenieNotifier) Send(ctx context.Context, wh models.Webhook, ev Event) error {
+	trigger, ok := incidentAction(ev)
+	if !ok {
+		return nil
+	}
+
+	base := strings.TrimSuffix(wh.URL, "/") + "/v2/alerts"
+	header := http.Header{"Authorization": {"GenieKey " + wh.Settings["api_key"]}}
+	alias := dedupKey(ev.Monitor)
+	if !trigger {
+		endpoint := base + "/" + url.PathEscape(alias) + "/close?identifierType=alias"
+		note := fmt.Sprintf("%s recovered", ev.Monitor.Name)
+		return sendJSON(ctx, http.MethodPost, endpoint, header, opsgenieClose{Source: "go-sentinel", Note: note})
+	}
+	return sendJSON(ctx, http.MethodPost, base, header, buildOpsgenieAlert(ev, alias))
+}
+
+func buildOpsgenieAlert(ev Event, alias string) opsgenieAlert {
+	msg := buildMessage(ev)
+	return opsgenieAlert{
+		Message:     truncate(msg.Title, 130),
+		Alias:       alias,
+		Description: strings.ReplaceAll(msg.Description, "**", ""),
+		Source:      "go-sentinel",
+		Priority:    "P1",
+		Entity:      ev.Monitor.Name,
+		Details:     incidentDetails(msg),
+	}
+}
+
+// truncate shortens s to at most n runes.
+func truncate(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+	return s
+}
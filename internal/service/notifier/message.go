@@ -0,0 +1,186 @@
+package notifier
+
+import (
+	"fmt"
+	"go-sentinel/internal/models"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	colorRed    = 0xE74C3C
+	colorGreen  = 0x2ECC71
+	colorOrange = 0xE67E22
+)
+
+// message is the provider-neutral content of an alert. Description may use
+// **bold** markdown, which providers translate to their own markup.
+type message struct {
+	Title       string
+	Description string
+	Color       int
+	Fields      []field
+}
+
+type field struct {
+	Name   string
+	Value  string
+	Inline bool
+}
+
+func buildMessage(ev Event) message {
+	switch ev.Kind {
+	case EventFlapping, EventStabilised:
+		return buildFlappingMessage(ev)
+	case EventCertificateExpiry:
+		return buildCertificateMessage(ev)
+	}
+	return buildStateMessage(ev)
+}
+
+func buildStateMessage(ev Event) message {
+	monitor, result := ev.Monitor, ev.Check
+	msg := message{
+		Title:       fmt.Sprintf("🔴 Monitor Down: %s", monitor.Name),
+		Description: fmt.Sprintf("**%s** is not responding. Immediate attention may be required.", monitor.Name),
+		Color:       colorRed,
+	}
+	if ev.Kind == EventRecovery {
+		msg.Title = fmt.Sprintf("✅ Monitor Recovered: %s", monitor.Name)
+		msg.Description = fmt.Sprintf("**%s** is back online and responding normally.", monitor.Name)
+		msg.Color = colorGreen
+	}
+
+	intervalText := fmt.Sprintf("Every %ds", monitor.Interval)
+	if monitor.Interval%60 == 0 {
+		intervalText = fmt.Sprintf("Every %dm", monitor.Interval/60)
+	}
+
+	msg.Fields = []field{{Name: "URL", Value: targetText(monitor)}}
+	if monitor.Type == models.MonitorTypeHTTP {
+		msg.Fields = append(msg.Fields, field{Name: "Status Code", Value: httpStatusText(result.StatusCode), Inline: true})
+	}
+	msg.Fields = append(msg.Fields,
+		field{Name: "Latency", Value: fmt.Sprintf("%dms", result.Latency), Inline: true},
+		field{Name: "Interval", Value: intervalText, Inline: true},
+	)
+	if downFor := ev.DownFor(); downFor > 0 {
+		msg.Fields = append(msg.Fields, field{Name: "Downtime", Value: downFor.Round(time.Second).String(), Inline: true})
+	}
+	if result.ErrorCategory != "" {
+		msg.Fields = append(msg.Fields, field{Name: "Reason", Value: errorText(result)})
+	}
+	if result.Assertion != "" {
+		msg.Fields = append(msg.Fields, field{Name: "Failed Assertion", Value: result.Assertion})
+	}
+	if len(ev.Dependents) > 0 {
+		msg.Fields = append(msg.Fields, field{Name: "Affected Monitors", Value: dependentsText(ev.Dependents)})
+	}
+	return msg
+}
+
+func buildFlappingMessage(ev Event) message {
+	monitor, result := ev.Monitor, ev.Check
+	state, color := "Up", colorGreen
+	if !result.IsUp {
+		state, color = "Down", colorRed
+	}
+
+	msg := message{
+		Title:       fmt.Sprintf("🔁 Monitor Flapping: %s", monitor.Name),
+		Description: fmt.Sprintf("**%s** keeps switching between up and down. Alerts for each change are paused until it settles.", monitor.Name),
+		Color:       colorOrange,
+	}
+	if ev.Kind == EventStabilised {
+		msg.Title = fmt.Sprintf("🟰 Monitor Stabilised: %s", monitor.Name)
+		msg.Description = fmt.Sprintf("**%s** has stopped flapping and is now **%s**.", monitor.Name, strings.ToLower(state))
+		msg.Color = color
+	}
+
+	msg.Fields = []field{
+		{Name: "URL", Value: targetText(monitor)},
+		{Name: "Current State", Value: state, Inline: true},
+	}
+	if result.ErrorCategory != "" {
+		msg.Fields = append(msg.Fields, field{Name: "Reason", Value: errorText(result)})
+	}
+	return msg
+}
+
+func buildCertificateMessage(ev Event) message {
+	monitor, cert := ev.Monitor, ev.Certificate
+	expiresIn := fmt.Sprintf("%d days", ev.DaysLeft)
+	if ev.DaysLeft == 1 {
+		expiresIn = "1 day"
+	} else if ev.DaysLeft < 1 {
+		expiresIn = "less than a day"
+	}
+
+	sans := strings.Join(cert.SANs, ", ")
+	if sans == "" {
+		sans = "—"
+	}
+
+	return message{
+		Title:       fmt.Sprintf("⚠️ Certificate Expiring: %s", monitor.Name),
+		Description: fmt.Sprintf("The TLS certificate for **%s** expires in %s. Renew it before it causes an outage.", monitor.Name, expiresIn),
+		Color:       colorOrange,
+		Fields: []field{
+			{Name: "URL", Value: targetText(monitor)},
+			{Name: "Expires", Value: cert.NotAfter.UTC().Format("2006-01-02 15:04 UTC"), Inline: true},
+			{Name: "Issuer", Value: cert.Issuer, Inline: true},
+			{Name: "Names", Value: sans},
+		},
+	}
+}
+
+// replaceBold rewrites **bold** markdown with the given open and close
+// markup, passing the text in between through escape.
+func replaceBold(text, open, close string, escape func(string) string) string {
+	var b strings.Builder
+	for i, part := range strings.Split(text, "**") {
+		if i%2 == 1 {
+			b.WriteString(open + escape(part) + close)
+		} else {
+			b.WriteString(escape(part))
+		}
+	}
+	return b.String()
+}
+
+// dependentsText lists dependent monitors within Discord's 1024 character
+// field limit.
+func dependentsText(names []string) string {
+	text := strings.Join(names, ", ")
+	if len(text) <= 1024 {
+		return text
+	}
+	return fmt.Sprintf("%s… (%d monitors)", text[:1000], len(names))
+}
+
+func errorText(result models.Check) string {
+	label := strings.ReplaceAll(result.ErrorCategory, "_", " ")
+	if result.ErrorMessage == "" || result.ErrorCategory == models.ErrorAssertion {
+		return label
+	}
+	return label + ": " + result.ErrorMessage
+}
+
+func targetText(monitor models.Monitor) string {
+	if monitor.Type == models.MonitorTypePush {
+		return "Push heartbeat"
+	}
+	return monitor.URL
+}
+
+func httpStatusText(code int) string {
+	if code <= 0 {
+		return "N/A — Connection failed"
+	}
+	text := http.StatusText(code)
+	if text == "" {
+		return fmt.Sprintf("%d", code)
+	}
+	return fmt.Sprintf("%d %s", code, text)
+}
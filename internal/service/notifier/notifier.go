@@ -0,0 +1,218 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"go-sentinel/internal/db"
+	"go-sentinel/internal/models"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type EventKind string
+
+const (
+	EventDown              EventKind = "down"
+	EventRecovery          EventKind = "recovery"
+	EventFlapping          EventKind = "flapping"
+	EventStabilised        EventKind = "stabilised"
+	EventCertificateExpiry EventKind = "certificate_expiry"
+)
+
+// Event is something worth telling people about. Check is the latest check
+// for state events; Certificate and DaysLeft are set for expiry warnings.
+type Event struct {
+	Kind        EventKind
+	Monitor     models.Monitor
+	Check       models.Check
+	Certificate models.Certificate
+	DaysLeft    int
+	Dependents  []string // monitors depending on this one, not alerted separately
+
+	// PreviousStatus is the monitor's status before a state change, held
+	// since PreviousSince. Either may be empty for a new monitor.
+	PreviousStatus string
+	PreviousSince  time.Time
+
+	Time time.Time
+}
+
+// DownFor returns how long a recovered monitor was down, or zero for any
+// other event.
+func (ev Event) DownFor() time.Duration {
+	if ev.Kind != EventRecovery || ev.PreviousSince.IsZero() || ev.Time.Before(ev.PreviousSince) {
+		return 0
+	}
+	return ev.Time.Sub(ev.PreviousSince)
+}
+
+// Notifier delivers an event to one notification channel in the format of
+// the service behind it.
+type Notifier interface {
+	Send(ctx context.Context, wh models.Webhook, ev Event) error
+}
+
+// notifiers maps webhook types to their delivery.
+var notifiers = map[string]Notifier{
+	models.WebhookTypeDiscord:   discordNotifier{},
+	models.WebhookTypeSlack:     slackNotifier{},
+	models.WebhookTypeTeams:     teamsNotifier{},
+	models.WebhookTypeTelegram:  telegramNotifier{},
+	models.WebhookTypeEmail:     emailNotifier{},
+	models.WebhookTypePagerDuty: pagerDutyNotifier{},
+	models.WebhookTypeOpsgenie:  opsgenieNotifier{},
+	models.WebhookTypeGeneric:   genericNotifier{},
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// pending tracks webhook deliveries still in flight.
+var pending sync.WaitGroup
+
+// Wait blocks until in-flight notifications have been delivered or ctx
+// ends.
+func Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for notifications: %w", ctx.Err())
+	}
+}
+
+// NotifyStateChange announces that a monitor went down or recovered. The
+// monitor had been in previousStatus since previousSince. dependents names
+// the monitors that depend on it, which are not alerted separately.
+func NotifyStateChange(ctx context.Context, database *sql.DB, monitor models.Monitor, result models.Check, previousStatus string, previousSince time.Time, dependents []string) {
+	kind := EventDown
+	if result.IsUp {
+		kind = EventRecovery
+	}
+	send(ctx, database, Event{
+		Kind: kind, Monitor: monitor, Check: result, Dependents: dependents,
+		PreviousStatus: previousStatus, PreviousSince: previousSince,
+	})
+}
+
+// NotifyFlapping announces that a monitor started changing state too often
+// to alert on each change, or that it has settled again. result is the
+// latest check.
+func NotifyFlapping(ctx context.Context, database *sql.DB, monitor models.Monitor, result models.Check, flapping bool) {
+	kind := EventStabilised
+	if flapping {
+		kind = EventFlapping
+	}
+	send(ctx, database, Event{Kind: kind, Monitor: monitor, Check: result})
+}
+
+// NotifyCertificateExpiry warns that a monitor's TLS certificate expires in
+// daysLeft days.
+func NotifyCertificateExpiry(ctx context.Context, database *sql.DB, monitor models.Monitor, cert models.Certificate, daysLeft int) {
+	send(ctx, database, Event{Kind: EventCertificateExpiry, Monitor: monitor, Certificate: cert, DaysLeft: daysLeft})
+}
+
+// send queues the event for every enabled channel and delivers it in the
+// background. Failed deliveries are retried by RetryDue.
+func send(ctx context.Context, database *sql.DB, ev Event) {
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+
+	webhooks, err := db.GetEnabledWebhooks(ctx, database)
+	if err != nil {
+		log.Printf("Notifier: failed to fetch webhooks: %v", err)
+		return
+	}
+
+	payload, err := encodeEvent(ev)
+	if err != nil {
+		log.Printf("Notifier: failed to encode %s event: %v", ev.Kind, err)
+		return
+	}
+
+	for _, wh := range webhooks {
+		if _, ok := notifiers[wh.Type]; !ok {
+			log.Printf("Notifier: webhook %s has unknown type %q", wh.Name, wh.Type)
+			continue
+		}
+		d := models.Delivery{WebhookID: wh.ID, Event: string(ev.Kind), MonitorID: ev.Monitor.ID, Payload: payload}
+		// If the outbox cannot be written the notification is still sent
+		// once, just without retries.
+		d.ID, err = db.CreateDelivery(ctx, database, d, time.Now().Add(deliveryLease), supersededEvents(ev.Kind))
+		if err != nil {
+			log.Printf("Notifier: failed to queue delivery to webhook %s: %v", wh.Name, err)
+		}
+		pending.Add(1)
+		go func() {
+			defer pending.Done()
+			attempt(ctx, database, d, wh, ev)
+		}()
+	}
+}
+
+// postJSON posts payload to url and fails on any non-2xx response.
+func postJSON(ctx context.Context, url string, payload any) error {
+	return sendJSON(ctx, http.MethodPost, url, nil, payload)
+}
+
+// sendJSON sends payload as JSON with the extra headers given and fails on
+// any non-2xx response.
+func sendJSON(ctx context.Context, method, url string, header http.Header, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+	header = header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Content-Type", "application/json")
+	return deliver(ctx, method, url, header, body)
+}
+
+// StatusError is returned for a non-2xx response. RetryAfter is the delay
+// the service asked for, if any.
+type StatusError struct {
+	Code       int
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("non-2xx status: %d", e.Code)
+}
+
+// deliver sends one request and fails with a *StatusError on any non-2xx
+// response.
+func deliver(ctx context.Context, method, url string, header http.Header, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	recordStatus(ctx, resp.StatusCode)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &StatusError{Code: resp.StatusCode, RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"go-sentinel/internal/models"
+	"strings"
+	"time"
+)
+
+// Slack allows at most ten fields per section block and 150 characters in
+// a header.
+const (
+	slackMaxFields = 10
+	slackMaxHeader = 150
+)
+
+type slackText struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	Emoji bool   `json:"emoji,omitempty"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Fields   []slackText `json:"fields,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+type slackAttachment struct {
+	Color  string       `json:"color"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackPayload struct {
+	Text        string            `json:"text"` // shown in notifications
+	Attachments []slackAttachment `json:"attachments"`
+}
+
+// slackNotifier posts Block Kit messages to a Slack incoming webhook. The
+// blocks sit in a coloured attachment so the severity shows at a glance.
+type slackNotifier struct{}
+
+func (slackNotifier) Send(ctx context.Context, wh models.Webhook, ev Event) error {
+	return postJSON(ctx, wh.URL, buildSlackPayload(buildMessage(ev), ev.Time))
+}
+
+func buildSlackPayload(msg message, at time.Time) slackPayload {
+	blocks := []slackBlock{
+		{Type: "header", Text: &slackText{Type: "plain_text", Text: truncate(msg.Title, slackMaxHeader), Emoji: true}},
+		{Type: "section", Text: &slackText{Type: "mrkdwn", Text: replaceBold(msg.Description, "*", "*", slackEscape)}},
+	}
+	for start := 0; start < len(msg.Fields); start += slackMaxFields {
+		end := min(start+slackMaxFields, len(msg.Fields))
+		var fields []slackText
+		for _, f := range msg.Fields[start:end] {
+			fields = append(fields, slackText{Type: "mrkdwn", Text: fmt.Sprintf("*%s*\n%s", slackEscape(f.Name), slackEscape(f.Value))})
+		}
+		blocks = append(blocks, slackBlock{Type: "section", Fields: fields})
+	}
+	blocks = append(blocks, slackBlock{Type: "context", Elements: []slackText{
+		{Type: "mrkdwn", Text: "go-sentinel · " + at.UTC().Format("2006-01-02 15:04 UTC")},
+	}})
+
+	return slackPayload{
+		Text:        msg.Title,
+		Attachments: []slackAttachment{{Color: fmt.Sprintf("#%06X", msg.Color), Blocks: blocks}},
+	}
+}
+
+// slackEscape escapes the characters Slack treats as control sequences in
+// mrkdwn text.
+var slackEscape = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
@@ -0,0 +1,84 @@
+package notifier
+
+import (
+	"context"
+	"go-sentinel/internal/models"
+	"time"
+)
+
+type teamsFact struct {
+	Title string `json:"title"`
+	Value string `json:"value"`
+}
+
+type teamsElement struct {
+	Type   string      `json:"type"`
+	Text   string      `json:"text,omitempty"`
+	Weight string      `json:"weight,omitempty"`
+	Size   string      `json:"size,omitempty"`
+	Color  string      `json:"color,omitempty"`
+	Wrap   bool        `json:"wrap,omitempty"`
+	Facts  []teamsFact `json:"facts,omitempty"`
+}
+
+type teamsCard struct {
+	Schema  string         `json:"$schema"`
+	Type    string         `json:"type"`
+	Version string         `json:"version"`
+	Body    []teamsElement `json:"body"`
+}
+
+type teamsAttachment struct {
+	ContentType string    `json:"contentType"`
+	Content     teamsCard `json:"content"`
+}
+
+type teamsPayload struct {
+	Type        string            `json:"type"`
+	Attachments []teamsAttachment `json:"attachments"`
+}
+
+// teamsNotifier posts an Adaptive Card, which both Teams incoming webhooks
+// and Workflows webhooks accept.
+type teamsNotifier struct{}
+
+func (teamsNotifier) Send(ctx context.Context, wh models.Webhook, ev Event) error {
+	return postJSON(ctx, wh.URL, buildTeamsPayload(buildMessage(ev), ev.Time))
+}
+
+func buildTeamsPayload(msg message, at time.Time) teamsPayload {
+	facts := make([]teamsFact, 0, len(msg.Fields))
+	for _, f := range msg.Fields {
+		facts = append(facts, teamsFact{Title: f.Name, Value: f.Value})
+	}
+
+	card := teamsCard{
+		Schema:  "http://adaptivecards.io/schemas/adaptive-card.json",
+		Type:    "AdaptiveCard",
+		Version: "1.4",
+		Body: []teamsElement{
+			{Type: "TextBlock", Text: msg.Title, Weight: "Bolder", Size: "Medium", Color: teamsColor(msg.Color), Wrap: true},
+			{Type: "TextBlock", Text: msg.Description, Wrap: true},
+			{Type: "FactSet", Facts: facts},
+			{Type: "TextBlock", Text: "go-sentinel · " + at.UTC().Format("2006-01-02 15:04 UTC"), Size: "Small", Color: "Accent", Wrap: true},
+		},
+	}
+	return teamsPayload{
+		Type:        "message",
+		Attachments: []teamsAttachment{{ContentType: "application/vnd.microsoft.card.adaptive", Content: card}},
+	}
+}
+
+// teamsColor maps a message colour to the closest Adaptive Card colour
+// name; cards cannot use arbitrary colours.
+func teamsColor(color int) string {
+	switch color {
+	case colorRed:
+		return "Attention"
+	case colorGreen:
+		return "Good"
+	case colorOrange:
+		return "Warning"
+	}
+	return "Default"
+}
@@ -0,0 +1,56 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"go-sentinel/internal/models"
+	"html"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// telegramMaxText is the Bot API's limit on a message's length.
+const telegramMaxText = 4096
+
+type telegramPayload struct {
+	ChatID                string `json:"chat_id"`
+	Text                  string `json:"text"`
+	ParseMode             string `json:"parse_mode"`
+	DisableWebPagePreview bool   `json:"disable_web_page_preview"`
+}
+
+// telegramNotifier sends a message through the Telegram Bot API. The
+// channel's URL is the Bot API server; the bot_token and chat_id settings
+// pick the bot and the chat.
+type telegramNotifier struct{}
+
+func (telegramNotifier) Send(ctx context.Context, wh models.Webhook, ev Event) error {
+	endpoint := strings.TrimSuffix(wh.URL, "/") + "/bot" + wh.Settings["bot_token"] + "/sendMessage"
+	err := postJSON(ctx, endpoint, buildTelegramPayload(buildMessage(ev), wh.Settings["chat_id"], ev.Time))
+
+	// The request URL carries the bot token; keep it out of the logs.
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = wh.URL
+	}
+	return err
+}
+
+func buildTelegramPayload(msg message, chatID string, at time.Time) telegramPayload {
+	var b strings.Builder
+	b.WriteString("<b>" + html.EscapeString(msg.Title) + "</b>\n\n")
+	b.WriteString(replaceBold(msg.Description, "<b>", "</b>", html.EscapeString) + "\n")
+	for _, f := range msg.Fields {
+		b.WriteString("\n<b>" + html.EscapeString(f.Name) + ":</b> " + html.EscapeString(f.Value))
+	}
+	b.WriteString("\n\n<i>go-sentinel · " + at.UTC().Format("2006-01-02 15:04 UTC") + "</i>")
+
+	text := b.String()
+	if len([]rune(text)) > telegramMaxText {
+		// Cutting through markup would make Telegram reject the message, so
+		// drop the fields instead.
+		text = "<b>" + html.EscapeString(msg.Title) + "</b>\n\n" + replaceBold(msg.Description, "<b>", "</b>", html.EscapeString)
+	}
+	return telegramPayload{ChatID: chatID, Text: text, ParseMode: "HTML", DisableWebPagePreview: true}
+}
@@ -8,11 +8,14 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
+	_ "time/tzdata" // maintenance window time zones; the runtime image has no zoneinfo
 
 	"go-sentinel/internal/api"
 	"go-sentinel/internal/db"
+	"go-sentinel/internal/service/checker"
 	"go-sentinel/internal/service/monitor"
 
 	_ "github.com/glebarez/go-sqlite"
@@ -23,6 +26,10 @@ import (
 var frontend embed.FS
 var Version = "dev"
 
+// workerStopTimeout bounds how long shutdown waits for running checks and
+// notifications before closing the database.
+const workerStopTimeout = 30 * time.Second
+
 func main() {
 	log.Printf("Go-Sentinel %s starting...", Version)
 
@@ -35,8 +42,23 @@ func main() {
 		dbPath     = getEnv("DB_PATH", "monitor.db")
 		port       = getEnv("PORT", "8088")
 		adminToken = os.Getenv("ADMIN_TOKEN")
+		checkAllow = os.Getenv("CHECK_ALLOWLIST")
+		checkDeny  = os.Getenv("CHECK_DENYLIST")
 	)
 
+	workerConfig := monitor.Config{
+		Workers:      getEnvInt("CHECK_WORKERS", monitor.DefaultWorkers),
+		QueueSize:    getEnvInt("CHECK_QUEUE_SIZE", monitor.DefaultQueueSize),
+		PerHostLimit: getEnvInt("CHECK_PER_HOST_LIMIT", monitor.DefaultPerHostLimit),
+	}
+
+	// Network Policy
+	policy, err := checker.ParsePolicy(checkAllow, checkDeny)
+	if err != nil {
+		log.Fatalf("Invalid check network policy: %v", err)
+	}
+	checker.SetPolicy(policy)
+
 	// Database Setup
 	database, err := sql.Open("sqlite", dbPath)
 	if err != nil {
@@ -59,10 +81,11 @@ func main() {
 	signalChan := make(chan os.Signal, 1)
 	signal.Notify(signalChan, os.Interrupt, syscall.SIGTERM)
 
-	monitor.StartWorker(ctx, database)
+	worker := monitor.StartWorker(database, workerConfig)
 
 	server := api.NewServer(database, Version)
 	server.AdminToken = adminToken
+	server.Worker = worker
 
 	if server.AdminToken == "" {
 		log.Println("WARNING: ADMIN_TOKEN is not set. Admin features are disabled (Read-only mode).")
@@ -77,13 +100,20 @@ func main() {
 		log.Printf("Warning: Failed to locate embedded frontend: %v", err)
 	}
 
-	go server.Start(ctx, port)
+	serverDone := make(chan struct{})
+	go func() {
+		server.Start(ctx, port)
+		close(serverDone)
+	}()
 
 	<-signalChan
 	log.Println("Received interrupt signal, shutting down...")
 	cancel()
+	<-serverDone
 
-	time.Sleep(3 * time.Second)
+	if err := worker.Stop(workerStopTimeout); err != nil {
+		log.Printf("Worker shutdown error: %v", err)
+	}
 
 	database.Close()
 	log.Println("Shutdown complete")
@@ -95,3 +125,15 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+func getEnvInt(key string, fallback int) int {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		log.Fatalf("%s must be a positive integer", key)
+	}
+	return n
+}
@@ -2,15 +2,19 @@ package tests
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"go-sentinel/internal/api"
 	"go-sentinel/internal/db"
 	"go-sentinel/internal/models"
+	"go-sentinel/internal/service/checker"
 
 	_ "github.com/glebarez/go-sqlite"
 )
@@ -132,6 +136,48 @@ func TestAPI(t *testing.T) {
 		}
 	})
 
+	t.Run("Monitor_Pause_Resume", func(t *testing.T) {
+		for _, tc := range []struct {
+			action string
+			active bool
+		}{
+			{"pause", false},
+			{"resume", true},
+		} {
+			req := httptest.NewRequest("POST", fmt.Sprintf("/monitors/%d/%s", monitorID, tc.action), nil)
+			req.Header.Set("Authorization", "secret")
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected 200 for %s, got %d", tc.action, w.Code)
+			}
+			var m models.Monitor
+			json.NewDecoder(w.Body).Decode(&m)
+			if m.Active != tc.active {
+				t.Errorf("Expected active=%v after %s, got %v", tc.active, tc.action, m.Active)
+			}
+		}
+	})
+
+	t.Run("Monitor_Pause_Unauthorized", func(t *testing.T) {
+		req := httptest.NewRequest("POST", fmt.Sprintf("/monitors/%d/pause", monitorID), nil)
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401, got %d", w.Code)
+		}
+	})
+
+	t.Run("Monitor_Pause_NotFound", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/monitors/99999/pause", nil)
+		req.Header.Set("Authorization", "secret")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404, got %d", w.Code)
+		}
+	})
+
 	t.Run("Monitor_History", func(t *testing.T) {
 		url := fmt.Sprintf("/history/%d", monitorID)
 		req := httptest.NewRequest("GET", url, nil)
@@ -415,6 +461,40 @@ func TestAPI(t *testing.T) {
 		}
 	})
 
+	t.Run("Monitor_List_Config_Hidden_For_Non_Admin", func(t *testing.T) {
+		monitors := []models.Monitor{
+			{Name: "Hidden HTTP", Type: models.MonitorTypeHTTP, URL: "http://internal.example.com", Interval: 60,
+				Method: "POST", AcceptedStatus: "200-299", Keyword: "ok", BodyRegex: "ready", JSONAssertions: []string{"$.status == \"ok\""}},
+			{Name: "Hidden TCP", Type: models.MonitorTypeTCP, URL: "db.example.com:5432", Interval: 60, ExpectedBanner: "PostgreSQL"},
+			{Name: "Hidden DNS", Type: models.MonitorTypeDNS, URL: "example.com", Interval: 60,
+				DNSRecordType: "A", DNSResolver: "1.1.1.1:53", DNSExpected: "93.184.216.34"},
+		}
+		for _, m := range monitors {
+			body, _ := json.Marshal(m)
+			req := httptest.NewRequest("POST", "/monitors", bytes.NewReader(body))
+			req.Header.Set("Authorization", "secret")
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, req)
+			if w.Code != http.StatusCreated {
+				t.Fatalf("Expected 201 for %s, got %d: %s", m.Name, w.Code, w.Body.String())
+			}
+		}
+
+		req := httptest.NewRequest("GET", "/monitors", nil)
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		body := w.Body.String()
+		for _, secret := range []string{"internal.example.com", "POST", "200-299", "\"ok\"", "ready", "$.status",
+			"db.example.com", "PostgreSQL", "1.1.1.1", "93.184.216.34", "dns_record_type"} {
+			if strings.Contains(body, secret) {
+				t.Errorf("Expected %q to be hidden for non-admin", secret)
+			}
+		}
+		if !strings.Contains(body, "Hidden DNS") {
+			t.Error("Expected monitor names to stay visible")
+		}
+	})
+
 	t.Run("Monitor_List_URL_Visible_For_Admin", func(t *testing.T) {
 		req := httptest.NewRequest("GET", "/monitors", nil)
 		req.Header.Set("Authorization", "secret")
@@ -483,6 +563,106 @@ func TestAPI(t *testing.T) {
 		}
 	})
 
+	t.Run("Monitor_Parent_Validation", func(t *testing.T) {
+		post := func(m models.Monitor) (*httptest.ResponseRecorder, models.Monitor) {
+			body, _ := json.Marshal(m)
+			req := httptest.NewRequest("POST", "/monitors", bytes.NewReader(body))
+			req.Header.Set("Authorization", "secret")
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, req)
+			var created models.Monitor
+			json.Unmarshal(w.Body.Bytes(), &created)
+			return w, created
+		}
+
+		if w, _ := post(models.Monitor{Name: "Orphan", URL: "http://orphan.example.com", Interval: 60, ParentID: 99999}); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for unknown parent, got %d", w.Code)
+		}
+
+		_, router := post(models.Monitor{Name: "Router", URL: "http://router.example.com", Interval: 60})
+		w, app := post(models.Monitor{Name: "App", URL: "http://app.example.com", Interval: 60, ParentID: router.ID})
+		if w.Code != http.StatusCreated || app.ParentID != router.ID {
+			t.Fatalf("Expected child to be created with parent, got %d: %+v", w.Code, app)
+		}
+
+		body, _ := json.Marshal(map[string]any{"id": router.ID, "parent_id": app.ID})
+		req := httptest.NewRequest("PUT", "/monitors", bytes.NewReader(body))
+		req.Header.Set("Authorization", "secret")
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for a dependency loop, got %d", w.Code)
+		}
+	})
+
+	t.Run("Maintenance_Create_And_List", func(t *testing.T) {
+		monitorID, err := db.CreateMonitor(context.Background(), dbConn, models.Monitor{Type: models.MonitorTypeHTTP, Name: "Database", URL: "http://db.example.com", Interval: 60})
+		if err != nil {
+			t.Fatalf("Failed to create monitor: %v", err)
+		}
+		mw := models.MaintenanceWindow{
+			Title: "DB patching", Timezone: "Europe/London",
+			Weekdays: []int{3}, StartTime: "01:00", Duration: 90,
+			MonitorIDs: []int64{monitorID},
+		}
+		body, _ := json.Marshal(mw)
+		req := httptest.NewRequest("POST", "/maintenance", bytes.NewReader(body))
+		req.Header.Set("Authorization", "secret")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+		}
+
+		req = httptest.NewRequest("GET", "/maintenance", nil)
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		var windows []models.MaintenanceWindow
+		json.NewDecoder(w.Body).Decode(&windows)
+		if len(windows) != 1 || windows[0].Title != "DB patching" || len(windows[0].MonitorIDs) != 1 || windows[0].MonitorIDs[0] != monitorID {
+			t.Errorf("Expected the created window with its monitor, got %+v", windows)
+		}
+	})
+
+	t.Run("Maintenance_Unknown_Monitor", func(t *testing.T) {
+		start := time.Now()
+		end := start.Add(time.Hour)
+		body, _ := json.Marshal(models.MaintenanceWindow{Title: "Ghost", StartsAt: &start, EndsAt: &end, MonitorIDs: []int64{99999}})
+		req := httptest.NewRequest("POST", "/maintenance", bytes.NewReader(body))
+		req.Header.Set("Authorization", "secret")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400, got %d", w.Code)
+		}
+	})
+
+	t.Run("History_Excludes_Maintenance", func(t *testing.T) {
+		id, err := db.CreateMonitor(context.Background(), dbConn, models.Monitor{Type: models.MonitorTypeHTTP, Name: "Patched", URL: "http://patched.example.com", Interval: 60})
+		if err != nil {
+			t.Fatalf("Failed to create monitor: %v", err)
+		}
+		for _, c := range []models.Check{
+			{MonitorID: id, IsUp: true},
+			{MonitorID: id, IsUp: false},
+			{MonitorID: id, IsUp: false, Maintenance: true},
+			{MonitorID: id, IsUp: false, Maintenance: true},
+		} {
+			if err := db.SaveCheckAndUpdateStats(context.Background(), dbConn, c); err != nil {
+				t.Fatalf("Failed to save check: %v", err)
+			}
+		}
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("/history/%d", id), nil)
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		var stats []models.DailyStat
+		json.NewDecoder(w.Body).Decode(&stats)
+		if len(stats) != 1 || stats[0].UptimePct != 50 || stats[0].MaintenanceCount != 2 {
+			t.Errorf("Expected 50%% uptime with 2 maintenance checks, got %+v", stats)
+		}
+	})
+
 	t.Run("History_All_Monitors", func(t *testing.T) {
 		req := httptest.NewRequest("GET", "/history", nil)
 		w := httptest.NewRecorder()
@@ -492,6 +672,45 @@ func TestAPI(t *testing.T) {
 		}
 	})
 
+	t.Run("History_Average_Timings", func(t *testing.T) {
+		m := models.Monitor{Name: "Timed", URL: "http://timed.example.com", Interval: 60}
+		id, err := db.CreateMonitor(context.Background(), dbConn, m)
+		if err != nil {
+			t.Fatalf("Failed to create monitor: %v", err)
+		}
+		m.ID = id
+		// The second check reuses the connection, so it has no DNS or
+		// connect phase and must not halve their averages.
+		for _, timings := range []models.Timings{{DNS: 10, Connect: 10, TTFB: 100}, {TTFB: 300}} {
+			check := models.Check{MonitorID: m.ID, StatusCode: 200, Latency: timings.TTFB + 20, IsUp: true, Timings: timings}
+			if err := db.SaveCheckAndUpdateStats(context.Background(), dbConn, check); err != nil {
+				t.Fatalf("Failed to save check: %v", err)
+			}
+		}
+
+		req := httptest.NewRequest("GET", fmt.Sprintf("/history/%d", m.ID), nil)
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		var stats []models.DailyStat
+		json.NewDecoder(w.Body).Decode(&stats)
+		if len(stats) != 1 {
+			t.Fatalf("Expected 1 day of stats, got %d", len(stats))
+		}
+		want := models.Timings{DNS: 10, Connect: 10, TTFB: 200}
+		if stats[0].AvgTimings != want {
+			t.Errorf("Expected avg_timings %+v, got %+v", want, stats[0].AvgTimings)
+		}
+
+		checksReq := httptest.NewRequest("GET", "/checks", nil)
+		checksW := httptest.NewRecorder()
+		s.ServeHTTP(checksW, checksReq)
+		var checks map[int64][]models.Check
+		json.NewDecoder(checksW.Body).Decode(&checks)
+		if len(checks[m.ID]) != 2 || checks[m.ID][0].Timings.DNS != 10 {
+			t.Errorf("Expected per-check timings in /checks, got %+v", checks[m.ID])
+		}
+	})
+
 	t.Run("History_Invalid_Monitor_ID", func(t *testing.T) {
 		req := httptest.NewRequest("GET", "/history/invalid", nil)
 		w := httptest.NewRecorder()
@@ -676,4 +895,514 @@ func TestAPI(t *testing.T) {
 			t.Errorf("Expected 201 for normal sized request, got %d", w.Code)
 		}
 	})
+
+	t.Run("Monitor_Create_TCP", func(t *testing.T) {
+		m := models.Monitor{Name: "Postgres", Type: models.MonitorTypeTCP, URL: "db.example.com:5432", Interval: 60}
+		body, _ := json.Marshal(m)
+		req := httptest.NewRequest("POST", "/monitors", bytes.NewReader(body))
+		req.Header.Set("Authorization", "secret")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Errorf("Expected 201 for tcp monitor, got %d", w.Code)
+		}
+	})
+
+	t.Run("Monitor_Create_TCP_Missing_Port", func(t *testing.T) {
+		m := models.Monitor{Name: "Postgres", Type: models.MonitorTypeTCP, URL: "db.example.com", Interval: 60}
+		body, _ := json.Marshal(m)
+		req := httptest.NewRequest("POST", "/monitors", bytes.NewReader(body))
+		req.Header.Set("Authorization", "secret")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for tcp address without port, got %d", w.Code)
+		}
+	})
+
+	t.Run("Monitor_Create_Unknown_Type", func(t *testing.T) {
+		m := models.Monitor{Name: "Test", Type: "icmp", URL: "http://test.com", Interval: 60}
+		body, _ := json.Marshal(m)
+		req := httptest.NewRequest("POST", "/monitors", bytes.NewReader(body))
+		req.Header.Set("Authorization", "secret")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for unknown monitor type, got %d", w.Code)
+		}
+	})
+
+	t.Run("Monitor_Create_DNS", func(t *testing.T) {
+		m := models.Monitor{Name: "DNS", Type: models.MonitorTypeDNS, URL: "example.com", DNSRecordType: "mx", DNSResolver: "1.1.1.1", Interval: 60}
+		body, _ := json.Marshal(m)
+		req := httptest.NewRequest("POST", "/monitors", bytes.NewReader(body))
+		req.Header.Set("Authorization", "secret")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected 201 for dns monitor, got %d", w.Code)
+		}
+		var created models.Monitor
+		json.NewDecoder(w.Body).Decode(&created)
+		if created.DNSRecordType != "MX" || created.DNSResolver != "1.1.1.1:53" {
+			t.Errorf("Expected normalized MX record via 1.1.1.1:53, got %s via %s", created.DNSRecordType, created.DNSResolver)
+		}
+	})
+
+	t.Run("Monitor_Create_DNS_Invalid_Record_Type", func(t *testing.T) {
+		m := models.Monitor{Name: "DNS", Type: models.MonitorTypeDNS, URL: "example.com", DNSRecordType: "SRV", Interval: 60}
+		body, _ := json.Marshal(m)
+		req := httptest.NewRequest("POST", "/monitors", bytes.NewReader(body))
+		req.Header.Set("Authorization", "secret")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for unsupported record type, got %d", w.Code)
+		}
+	})
+
+	t.Run("Monitor_Certificate", func(t *testing.T) {
+		m := models.Monitor{Name: "Cert Test", URL: "https://secure.com", Interval: 60}
+		body, _ := json.Marshal(m)
+		req := httptest.NewRequest("POST", "/monitors", bytes.NewReader(body))
+		req.Header.Set("Authorization", "secret")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		var created models.Monitor
+		json.NewDecoder(w.Body).Decode(&created)
+
+		url := fmt.Sprintf("/monitors/%d/certificate", created.ID)
+		req = httptest.NewRequest("GET", url, nil)
+		req.Header.Set("Authorization", "secret")
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 before any certificate is recorded, got %d", w.Code)
+		}
+
+		cert := models.Certificate{
+			MonitorID: created.ID,
+			Subject:   "secure.com",
+			Issuer:    "Test CA",
+			SANs:      []string{"secure.com", "www.secure.com"},
+			NotBefore: time.Now().Add(-24 * time.Hour),
+			NotAfter:  time.Now().Add(10 * 24 * time.Hour),
+		}
+		if err := db.SaveCertificate(context.Background(), dbConn, cert); err != nil {
+			t.Fatalf("Failed to save certificate: %v", err)
+		}
+
+		req = httptest.NewRequest("GET", url, nil)
+		req.Header.Set("Authorization", "secret")
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", w.Code)
+		}
+		var got models.Certificate
+		json.NewDecoder(w.Body).Decode(&got)
+		if got.Issuer != "Test CA" || len(got.SANs) != 2 {
+			t.Errorf("Unexpected certificate: %+v", got)
+		}
+		if days := got.DaysLeft(time.Now()); days != 9 {
+			t.Errorf("Expected 9 days left, got %d", days)
+		}
+
+		req = httptest.NewRequest("GET", url, nil)
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401 without auth, got %d", w.Code)
+		}
+	})
+
+	t.Run("Monitor_Create_Keyword_Assertion", func(t *testing.T) {
+		m := models.Monitor{Name: "Keyword", URL: "https://example.com", Interval: 60, Keyword: "Internal Server Error", InvertKeyword: true, BodyRegex: `"status":\s*"ok"`}
+		body, _ := json.Marshal(m)
+		req := httptest.NewRequest("POST", "/monitors", bytes.NewReader(body))
+		req.Header.Set("Authorization", "secret")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Errorf("Expected 201 for keyword assertions, got %d", w.Code)
+		}
+	})
+
+	t.Run("Monitor_Create_Invalid_Body_Regex", func(t *testing.T) {
+		m := models.Monitor{Name: "Regex", URL: "https://example.com", Interval: 60, BodyRegex: "(unclosed"}
+		body, _ := json.Marshal(m)
+		req := httptest.NewRequest("POST", "/monitors", bytes.NewReader(body))
+		req.Header.Set("Authorization", "secret")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for invalid regex, got %d", w.Code)
+		}
+	})
+
+	t.Run("Monitor_Create_Invalid_JSON_Assertion", func(t *testing.T) {
+		m := models.Monitor{Name: "JSON", URL: "https://example.com/health", Interval: 60, JSONAssertions: []string{`$.status = "ok"`}}
+		body, _ := json.Marshal(m)
+		req := httptest.NewRequest("POST", "/monitors", bytes.NewReader(body))
+		req.Header.Set("Authorization", "secret")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for invalid json assertion, got %d", w.Code)
+		}
+	})
+
+	t.Run("Monitor_JSON_Assertions_Persisted", func(t *testing.T) {
+		m := models.Monitor{Name: "JSON Persist", URL: "https://example.com/health", Interval: 60, JSONAssertions: []string{`$.status == "ok"`, `$.queue_depth < 1000`}}
+		body, _ := json.Marshal(m)
+		req := httptest.NewRequest("POST", "/monitors", bytes.NewReader(body))
+		req.Header.Set("Authorization", "secret")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected 201, got %d", w.Code)
+		}
+
+		req = httptest.NewRequest("GET", "/monitors", nil)
+		req.Header.Set("Authorization", "secret")
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		var monitors []models.Monitor
+		json.NewDecoder(w.Body).Decode(&monitors)
+		for _, mon := range monitors {
+			if mon.Name == "JSON Persist" {
+				if len(mon.JSONAssertions) != 2 {
+					t.Errorf("Expected 2 json assertions, got %v", mon.JSONAssertions)
+				}
+				return
+			}
+		}
+		t.Error("Created monitor not found")
+	})
+
+	t.Run("Monitor_Create_Custom_Request", func(t *testing.T) {
+		m := models.Monitor{
+			Name:           "Auth API",
+			URL:            "https://api.example.com/v1/jobs",
+			Interval:       60,
+			Method:         "post",
+			Headers:        map[string]string{"Authorization": "Bearer token", "Content-Type": "application/json"},
+			Body:           `{"ping":true}`,
+			AcceptedStatus: "200,204,401",
+		}
+		body, _ := json.Marshal(m)
+		req := httptest.NewRequest("POST", "/monitors", bytes.NewReader(body))
+		req.Header.Set("Authorization", "secret")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected 201 for custom request monitor, got %d", w.Code)
+		}
+
+		req = httptest.NewRequest("GET", "/monitors", nil)
+		req.Header.Set("Authorization", "secret")
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		var monitors []models.Monitor
+		json.NewDecoder(w.Body).Decode(&monitors)
+		for _, mon := range monitors {
+			if mon.Name == "Auth API" && (mon.Method != "POST" || mon.AcceptedStatus != "200,204,401") {
+				t.Errorf("Unexpected request settings: %s %s", mon.Method, mon.AcceptedStatus)
+			}
+		}
+
+		req = httptest.NewRequest("GET", "/monitors", nil)
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		monitors = nil
+		json.NewDecoder(w.Body).Decode(&monitors)
+		for _, mon := range monitors {
+			if mon.Name == "Auth API" && (len(mon.Headers) != 0 || mon.Body != "" || mon.Method != "") {
+				t.Error("Expected request settings to be hidden for non-admin")
+			}
+		}
+	})
+
+	t.Run("Monitor_Create_Invalid_Status_Codes", func(t *testing.T) {
+		for _, codes := range []string{"abc", "200-100", "99", "200,"} {
+			m := models.Monitor{Name: "Codes", URL: "https://example.com", Interval: 60, AcceptedStatus: codes}
+			body, _ := json.Marshal(m)
+			req := httptest.NewRequest("POST", "/monitors", bytes.NewReader(body))
+			req.Header.Set("Authorization", "secret")
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected 400 for accepted_status_codes %q, got %d", codes, w.Code)
+			}
+		}
+	})
+
+	t.Run("Monitor_Create_Invalid_Method", func(t *testing.T) {
+		m := models.Monitor{Name: "Method", URL: "https://example.com", Interval: 60, Method: "TRACE"}
+		body, _ := json.Marshal(m)
+		req := httptest.NewRequest("POST", "/monitors", bytes.NewReader(body))
+		req.Header.Set("Authorization", "secret")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for unsupported method, got %d", w.Code)
+		}
+	})
+
+	t.Run("Monitor_Create_Retry_Settings", func(t *testing.T) {
+		m := models.Monitor{Name: "Retry", URL: "https://example.com", Interval: 60, Timeout: 15, ConfirmAfter: 3, RetryInterval: 20}
+		body, _ := json.Marshal(m)
+		req := httptest.NewRequest("POST", "/monitors", bytes.NewReader(body))
+		req.Header.Set("Authorization", "secret")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Errorf("Expected 201 for retry settings, got %d", w.Code)
+		}
+	})
+
+	t.Run("Monitor_Create_Invalid_Retry_Settings", func(t *testing.T) {
+		invalid := []models.Monitor{
+			{Name: "Timeout", URL: "https://example.com", Interval: 60, Timeout: 61},
+			{Name: "Confirm", URL: "https://example.com", Interval: 60, ConfirmAfter: 11},
+			{Name: "Retry", URL: "https://example.com", Interval: 60, RetryInterval: 120},
+			{Name: "Retry", URL: "https://example.com", Interval: 60, RetryInterval: 5},
+		}
+		for _, m := range invalid {
+			body, _ := json.Marshal(m)
+			req := httptest.NewRequest("POST", "/monitors", bytes.NewReader(body))
+			req.Header.Set("Authorization", "secret")
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected 400 for %+v, got %d", m, w.Code)
+			}
+		}
+	})
+
+	t.Run("Monitor_Push_Heartbeat", func(t *testing.T) {
+		m := models.Monitor{Name: "Nightly backup", Type: models.MonitorTypePush, Interval: 86400, GracePeriod: 3600}
+		body, _ := json.Marshal(m)
+		req := httptest.NewRequest("POST", "/monitors", bytes.NewReader(body))
+		req.Header.Set("Authorization", "secret")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("Expected 201 for push monitor, got %d", w.Code)
+		}
+		var created models.Monitor
+		json.NewDecoder(w.Body).Decode(&created)
+		if created.PushToken == "" {
+			t.Fatal("Expected a push token to be issued")
+		}
+
+		stored, _ := db.GetMonitor(context.Background(), dbConn, created.ID)
+		if !checker.Perform(context.Background(), *stored).IsUp {
+			t.Error("Expected new push monitor to be up until its first deadline")
+		}
+		overdue := time.Now().Add(-26 * time.Hour)
+		stored.LastPushAt = &overdue
+		if checker.Perform(context.Background(), *stored).IsUp {
+			t.Error("Expected push monitor without a recent heartbeat to be down")
+		}
+
+		req = httptest.NewRequest("POST", "/push/"+created.PushToken, nil)
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200 for heartbeat, got %d", w.Code)
+		}
+
+		stored, _ = db.GetMonitor(context.Background(), dbConn, created.ID)
+		if !checker.Perform(context.Background(), *stored).IsUp {
+			t.Error("Expected push monitor to be up after heartbeat")
+		}
+
+		req = httptest.NewRequest("GET", "/monitors", nil)
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		var monitors []models.Monitor
+		json.NewDecoder(w.Body).Decode(&monitors)
+		for _, mon := range monitors {
+			if mon.PushToken != "" {
+				t.Error("Expected push token to be hidden for non-admin")
+			}
+		}
+	})
+
+	t.Run("Monitor_Push_Unknown_Token", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/push/does-not-exist", nil)
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 for unknown token, got %d", w.Code)
+		}
+	})
+
+	t.Run("Monitor_Push_Rejects_URL", func(t *testing.T) {
+		m := models.Monitor{Name: "Push", Type: models.MonitorTypePush, URL: "http://test.com", Interval: 60}
+		body, _ := json.Marshal(m)
+		req := httptest.NewRequest("POST", "/monitors", bytes.NewReader(body))
+		req.Header.Set("Authorization", "secret")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for push monitor with URL, got %d", w.Code)
+		}
+	})
+
+	t.Run("Monitor_Update_Keeps_Omitted_Settings", func(t *testing.T) {
+		m := models.Monitor{Name: "Partial", URL: "https://example.com", Interval: 60, Keyword: "healthy", Headers: map[string]string{"X-Key": "1"}}
+		body, _ := json.Marshal(m)
+		req := httptest.NewRequest("POST", "/monitors", bytes.NewReader(body))
+		req.Header.Set("Authorization", "secret")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		var created models.Monitor
+		json.NewDecoder(w.Body).Decode(&created)
+
+		update := fmt.Sprintf(`{"id":%d,"name":"Partial Renamed","url":"https://example.com","interval":120}`, created.ID)
+		req = httptest.NewRequest("PUT", "/monitors", bytes.NewReader([]byte(update)))
+		req.Header.Set("Authorization", "secret")
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d", w.Code)
+		}
+
+		stored, _ := db.GetMonitor(context.Background(), dbConn, created.ID)
+		if stored.Name != "Partial Renamed" || stored.Interval != 120 {
+			t.Errorf("Expected update to apply, got %+v", stored)
+		}
+		if stored.Keyword != "healthy" || stored.Headers["X-Key"] != "1" {
+			t.Errorf("Expected omitted settings to be kept, got %+v", stored)
+		}
+	})
+
+	t.Run("Monitor_Update_Not_Found", func(t *testing.T) {
+		req := httptest.NewRequest("PUT", "/monitors", bytes.NewReader([]byte(`{"id":99999,"name":"x","url":"https://example.com","interval":60}`)))
+		req.Header.Set("Authorization", "secret")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 for unknown monitor, got %d", w.Code)
+		}
+	})
+
+	t.Run("Monitor_Create_Blocked_By_Policy", func(t *testing.T) {
+		for _, target := range []string{"http://127.0.0.1:8080", "http://10.0.0.5/health", "http://169.254.169.254/latest/meta-data"} {
+			m := models.Monitor{Name: "Internal", URL: target, Interval: 60}
+			body, _ := json.Marshal(m)
+			req := httptest.NewRequest("POST", "/monitors", bytes.NewReader(body))
+			req.Header.Set("Authorization", "secret")
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("Expected 400 for %s under the default policy, got %d", target, w.Code)
+			}
+		}
+	})
+
+	t.Run("Checks_Error_Details", func(t *testing.T) {
+		m := models.Monitor{Name: "Failing", URL: "https://failing.example.com", Interval: 60}
+		body, _ := json.Marshal(m)
+		req := httptest.NewRequest("POST", "/monitors", bytes.NewReader(body))
+		req.Header.Set("Authorization", "secret")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		var created models.Monitor
+		json.NewDecoder(w.Body).Decode(&created)
+
+		check := models.Check{MonitorID: created.ID, ErrorCategory: models.ErrorDNS, ErrorMessage: "lookup failing.example.com: no such host"}
+		if err := db.SaveCheckAndUpdateStats(context.Background(), dbConn, check); err != nil {
+			t.Fatalf("Failed to save check: %v", err)
+		}
+
+		for _, admin := range []bool{false, true} {
+			req = httptest.NewRequest("GET", "/checks", nil)
+			if admin {
+				req.Header.Set("Authorization", "secret")
+			}
+			w = httptest.NewRecorder()
+			s.ServeHTTP(w, req)
+			var checks map[int64][]models.Check
+			json.NewDecoder(w.Body).Decode(&checks)
+			got := checks[created.ID]
+			if len(got) != 1 || got[0].ErrorCategory != models.ErrorDNS {
+				t.Fatalf("Expected dns failure category, got %+v", got)
+			}
+			if admin != (got[0].ErrorMessage != "") {
+				t.Errorf("Expected error message visible only to admin (admin=%v), got %q", admin, got[0].ErrorMessage)
+			}
+		}
+	})
+
+	t.Run("Webhook_Types", func(t *testing.T) {
+		tests := []struct {
+			body string
+			code int
+		}{
+			{`{"name":"Slack","type":"slack","url":"https://hooks.slack.com/services/T/B/X"}`, http.StatusCreated},
+			{`{"name":"Legacy","url":"https://discord.com/api/webhooks/1/x"}`, http.StatusCreated},
+			{`{"name":"Telegram","type":"telegram","settings":{"bot_token":"123:abc","chat_id":"-100"}}`, http.StatusCreated},
+			{`{"name":"Telegram","type":"telegram","settings":{"bot_token":"123:abc"}}`, http.StatusBadRequest},
+			{`{"name":"Pager","type":"pager","url":"https://example.com"}`, http.StatusBadRequest},
+			{`{"name":"Bad URL","type":"teams","url":"ftp://example.com"}`, http.StatusBadRequest},
+			{`{"name":"Hook","type":"generic","url":"https://example.com","settings":{"body":"{{.Monitor.Name"}}`, http.StatusBadRequest},
+			{`{"name":"Hook","type":"generic","url":"https://example.com","settings":{"method":"DELETE"}}`, http.StatusBadRequest},
+			{`{"name":"Hook","type":"slack","url":"https://example.com","headers":{"X-Token":"a"}}`, http.StatusBadRequest},
+			{`{"name":"Hook","type":"generic","url":"https://example.com","headers":{"X-Token":"a"},"settings":{"body":"{{json .Title}}"}}`, http.StatusCreated},
+		}
+		for _, tt := range tests {
+			req := httptest.NewRequest("POST", "/webhooks", bytes.NewBufferString(tt.body))
+			req.Header.Set("Authorization", "secret")
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, req)
+			if w.Code != tt.code {
+				t.Fatalf("Expected %d for %s, got %d: %s", tt.code, tt.body, w.Code, w.Body.String())
+			}
+			if tt.code != http.StatusCreated {
+				continue
+			}
+			var created models.Webhook
+			json.NewDecoder(w.Body).Decode(&created)
+			if created.Type == "" || created.URL == "" {
+				t.Errorf("Expected type and URL to be filled in, got %+v", created)
+			}
+		}
+	})
+
+	t.Run("Webhook_Update_Keeps_Type", func(t *testing.T) {
+		id, err := db.CreateWebhook(context.Background(), dbConn, models.Webhook{
+			Name: "Team", Type: models.WebhookTypeTeams, URL: "https://example.webhook.office.com/x", Enabled: true,
+		})
+		if err != nil {
+			t.Fatalf("Failed to create webhook: %v", err)
+		}
+
+		body := `{"name":"Team","url":"https://example.webhook.office.com/x","enabled":false}`
+		req := httptest.NewRequest("PUT", fmt.Sprintf("/webhooks/%d", id), bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "secret")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("Expected 204, got %d: %s", w.Code, w.Body.String())
+		}
+
+		wh, err := db.GetWebhook(context.Background(), dbConn, id)
+		if err != nil || wh == nil {
+			t.Fatalf("Failed to load webhook: %v", err)
+		}
+		if wh.Type != models.WebhookTypeTeams || wh.Enabled {
+			t.Errorf("Expected a disabled teams webhook, got %+v", wh)
+		}
+
+		req = httptest.NewRequest("PUT", "/webhooks/9999", bytes.NewBufferString(body))
+		req.Header.Set("Authorization", "secret")
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404, got %d", w.Code)
+		}
+	})
 }
@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"testing"
+
+	"go-sentinel/internal/assertion"
+)
+
+func TestJSONAssertions(t *testing.T) {
+	body := []byte(`{"status":"ok","queue_depth":250,"deps":[{"name":"db","healthy":true}],"version":"1.4.2"}`)
+
+	tests := []struct {
+		assertion string
+		wantPass  bool
+	}{
+		{`$.status == "ok"`, true},
+		{`$.status != "ok"`, false},
+		{`$.queue_depth < 1000`, true},
+		{`$.queue_depth >= 1000`, false},
+		{`$.deps[0].healthy == true`, true},
+		{`$["deps"][0].name == "db"`, true},
+		{`$.deps[3].name == "db"`, false},
+		{`$.missing == null`, false},
+		{`$.deps[0].name < "redis"`, true},
+		{`$.status >= "pending"`, false},
+		{`$.status < 5`, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.assertion, func(t *testing.T) {
+			a, err := assertion.Parse(tc.assertion)
+			if err != nil {
+				t.Fatalf("Failed to parse: %v", err)
+			}
+			pass, detail := assertion.EvaluateBody(body, []assertion.Assertion{a})
+			if pass != tc.wantPass {
+				t.Errorf("Expected pass=%v, got %v (%s)", tc.wantPass, pass, detail)
+			}
+		})
+	}
+}
+
+func TestJSONAssertions_Invalid(t *testing.T) {
+	invalid := []string{
+		`status == "ok"`,
+		`$.status`,
+		`$.status == ok`,
+		`$.status =~ "ok"`,
+		`$.items[abc] == 1`,
+		`$.count < true`,
+	}
+	for _, raw := range invalid {
+		if _, err := assertion.Parse(raw); err == nil {
+			t.Errorf("Expected %q to be rejected", raw)
+		}
+	}
+}
+
+func TestJSONAssertions_FailureDetail(t *testing.T) {
+	a, _ := assertion.Parse(`$.status == "ok"`)
+	pass, detail := assertion.EvaluateBody([]byte(`{"status":"degraded"}`), []assertion.Assertion{a})
+	if pass {
+		t.Fatal("Expected assertion to fail")
+	}
+	if detail != `$.status == "ok" (got "degraded")` {
+		t.Errorf("Unexpected failure detail: %s", detail)
+	}
+
+	if pass, _ := assertion.EvaluateBody([]byte(`<html>`), []assertion.Assertion{a}); pass {
+		t.Error("Expected non-JSON body to fail")
+	}
+}
@@ -0,0 +1,377 @@
+package tests
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go-sentinel/internal/models"
+	"go-sentinel/internal/service/checker"
+)
+
+// allowLoopback lets checks reach local test servers for the duration of
+// the test.
+func allowLoopback(t *testing.T) {
+	t.Helper()
+	p, err := checker.ParsePolicy("127.0.0.0/8", "")
+	if err != nil {
+		t.Fatalf("Failed to parse policy: %v", err)
+	}
+	prev := checker.CurrentPolicy()
+	checker.SetPolicy(p)
+	t.Cleanup(func() { checker.SetPolicy(prev) })
+}
+
+func TestTCPCheck_LoopbackRefused(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer ln.Close()
+
+	m := models.Monitor{Type: models.MonitorTypeTCP, URL: ln.Addr().String()}
+	result := checker.Perform(context.Background(), m)
+	if result.IsUp {
+		t.Error("Expected loopback TCP target to be refused")
+	}
+}
+
+// startStubDNS answers every A query with the given IPv4 address.
+func startStubDNS(t *testing.T, answer net.IP) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if resp := stubDNSResponse(buf[:n], answer.To4()); resp != nil {
+				conn.WriteTo(resp, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func stubDNSResponse(query []byte, ip net.IP) []byte {
+	if len(query) < 12 {
+		return nil
+	}
+	// Question section: QNAME labels, then QTYPE and QCLASS.
+	end := 12
+	for end < len(query) && query[end] != 0 {
+		end += int(query[end]) + 1
+	}
+	end += 5
+	if end > len(query) {
+		return nil
+	}
+	question := query[12:end]
+	qtype := binary.BigEndian.Uint16(question[len(question)-4:])
+
+	resp := make([]byte, 12, 64)
+	copy(resp, query[:2])
+	binary.BigEndian.PutUint16(resp[2:], 0x8180) // response, recursion desired/available
+	binary.BigEndian.PutUint16(resp[4:], 1)
+	if qtype == 1 {
+		binary.BigEndian.PutUint16(resp[6:], 1)
+	}
+	resp = append(resp, question...)
+	if qtype == 1 {
+		resp = append(resp, 0xc0, 0x0c, 0, 1, 0, 1, 0, 0, 0, 60, 0, 4)
+		resp = append(resp, ip...)
+	}
+	return resp
+}
+
+func TestDNSCheck(t *testing.T) {
+	allowLoopback(t)
+	resolver := startStubDNS(t, net.ParseIP("192.0.2.10"))
+
+	tests := []struct {
+		name     string
+		expected string
+		wantUp   bool
+	}{
+		{"NoExpectation", "", true},
+		{"ExpectedMatch", "192.0.2.10", true},
+		{"ExpectedMismatch", "192.0.2.99", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m := models.Monitor{
+				Type:          models.MonitorTypeDNS,
+				URL:           "service.example.test",
+				DNSRecordType: "A",
+				DNSResolver:   resolver,
+				DNSExpected:   tc.expected,
+			}
+			result := checker.Perform(context.Background(), m)
+			if result.IsUp != tc.wantUp {
+				t.Errorf("Expected IsUp=%v, got %v", tc.wantUp, result.IsUp)
+			}
+		})
+	}
+}
+
+func TestTCPCheck_Banner(t *testing.T) {
+	allowLoopback(t)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Write([]byte("220 mail.example.test ESMTP ready\r\n"))
+			conn.Close()
+		}
+	}()
+
+	tests := []struct {
+		name   string
+		banner string
+		wantUp bool
+	}{
+		{"ConnectOnly", "", true},
+		{"BannerMatch", "ESMTP", true},
+		{"BannerMismatch", "+OK POP3", false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m := models.Monitor{Type: models.MonitorTypeTCP, URL: ln.Addr().String(), ExpectedBanner: tc.banner}
+			if got := checker.Perform(context.Background(), m).IsUp; got != tc.wantUp {
+				t.Errorf("Expected IsUp=%v, got %v", tc.wantUp, got)
+			}
+		})
+	}
+}
+
+func TestHTTPCheck(t *testing.T) {
+	allowLoopback(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/health":
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"status":"degraded","queue_depth":12}`))
+		case "/login":
+			if r.Method != http.MethodPost || r.Header.Get("X-Api-Key") != "k" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			body, _ := io.ReadAll(r.Body)
+			if string(body) != "ping" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.Write([]byte("<html>Internal Server Error</html>"))
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name          string
+		monitor       models.Monitor
+		wantUp        bool
+		wantAssertion string
+	}{
+		{"PlainUp", models.Monitor{URL: srv.URL + "/"}, true, ""},
+		{"KeywordMissing", models.Monitor{URL: srv.URL + "/", Keyword: "Welcome"}, false, `body does not contain "Welcome"`},
+		{"InvertedKeywordFound", models.Monitor{URL: srv.URL + "/", Keyword: "Internal Server Error", InvertKeyword: true}, false, `body contains "Internal Server Error"`},
+		{"RegexMatch", models.Monitor{URL: srv.URL + "/", BodyRegex: `<html>.*</html>`}, true, ""},
+		{"JSONPass", models.Monitor{URL: srv.URL + "/health", JSONAssertions: []string{`$.queue_depth < 1000`}}, true, ""},
+		{"JSONFail", models.Monitor{URL: srv.URL + "/health", JSONAssertions: []string{`$.status == "ok"`}}, false, `$.status == "ok" (got "degraded")`},
+		{"ExpectedUnauthorized", models.Monitor{URL: srv.URL + "/login", Method: "POST", Headers: map[string]string{"X-Api-Key": "k"}, Body: "ping", AcceptedStatus: "401"}, true, ""},
+		{"DefaultRejectsUnauthorized", models.Monitor{URL: srv.URL + "/login", Method: "POST", Headers: map[string]string{"X-Api-Key": "k"}, Body: "ping"}, false, ""},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.monitor.Type = models.MonitorTypeHTTP
+			result := checker.Perform(context.Background(), tc.monitor)
+			if result.IsUp != tc.wantUp {
+				t.Errorf("Expected IsUp=%v, got %v (status %d)", tc.wantUp, result.IsUp, result.StatusCode)
+			}
+			if result.Assertion != tc.wantAssertion {
+				t.Errorf("Expected assertion %q, got %q", tc.wantAssertion, result.Assertion)
+			}
+		})
+	}
+}
+
+func TestHTTPCheck_Timeout(t *testing.T) {
+	allowLoopback(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(1500 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	m := models.Monitor{Type: models.MonitorTypeHTTP, URL: srv.URL, Timeout: 1}
+	start := time.Now()
+	if checker.Perform(context.Background(), m).IsUp {
+		t.Error("Expected slow response to time out")
+	}
+	if elapsed := time.Since(start); elapsed > 1400*time.Millisecond {
+		t.Errorf("Expected check to stop after the 1s timeout, took %v", elapsed)
+	}
+}
+
+func TestHTTPCheck_Timings(t *testing.T) {
+	allowLoopback(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte("first"))
+		w.(http.Flusher).Flush()
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte("second"))
+	}))
+	defer srv.Close()
+
+	result := checker.Perform(context.Background(), models.Monitor{Type: models.MonitorTypeHTTP, URL: srv.URL, Method: "GET"})
+	if !result.IsUp {
+		t.Fatalf("Expected monitor to be up, got %q", result.ErrorMessage)
+	}
+	if result.Timings.TTFB < 40 {
+		t.Errorf("Expected TTFB to include server delay, got %dms", result.Timings.TTFB)
+	}
+	if result.Timings.Transfer < 40 {
+		t.Errorf("Expected transfer to include body delay, got %dms", result.Timings.Transfer)
+	}
+	if result.Timings.TLS != 0 {
+		t.Errorf("Expected no TLS phase for plain HTTP, got %dms", result.Timings.TLS)
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	p, err := checker.ParsePolicy("10.0.0.0/8,*.internal", "10.0.5.0/24,secret.internal,203.0.113.7")
+	if err != nil {
+		t.Fatalf("Failed to parse policy: %v", err)
+	}
+	prev := checker.CurrentPolicy()
+	checker.SetPolicy(p)
+	defer checker.SetPolicy(prev)
+
+	tests := []struct {
+		monitor models.Monitor
+		wantErr bool
+	}{
+		{models.Monitor{Type: models.MonitorTypeHTTP, URL: "http://10.1.2.3/health"}, false},
+		{models.Monitor{Type: models.MonitorTypeHTTP, URL: "http://10.0.5.9/health"}, true},
+		{models.Monitor{Type: models.MonitorTypeHTTP, URL: "http://192.168.1.1/"}, true},
+		{models.Monitor{Type: models.MonitorTypeHTTP, URL: "http://203.0.113.7/"}, true},
+		{models.Monitor{Type: models.MonitorTypeTCP, URL: "db.internal:5432"}, false},
+		{models.Monitor{Type: models.MonitorTypeTCP, URL: "secret.internal:5432"}, true},
+		{models.Monitor{Type: models.MonitorTypeDNS, URL: "example.com", DNSResolver: "127.0.0.1:53"}, true},
+	}
+	for _, tc := range tests {
+		err := checker.ValidateTarget(tc.monitor)
+		if (err != nil) != tc.wantErr {
+			t.Errorf("ValidateTarget(%s %s): expected error=%v, got %v", tc.monitor.Type, tc.monitor.URL, tc.wantErr, err)
+		}
+	}
+
+	if _, err := checker.ParsePolicy("10.0.0.0/33", ""); err == nil {
+		t.Error("Expected invalid CIDR to be rejected")
+	}
+}
+
+func TestPolicy_DenyNetworkOverridesAllowedHost(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer ln.Close()
+
+	p, err := checker.ParsePolicy("localhost", "127.0.0.0/8,::1/128")
+	if err != nil {
+		t.Fatalf("Failed to parse policy: %v", err)
+	}
+	prev := checker.CurrentPolicy()
+	checker.SetPolicy(p)
+	defer checker.SetPolicy(prev)
+
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	m := models.Monitor{Type: models.MonitorTypeTCP, URL: net.JoinHostPort("localhost", port)}
+	result := checker.Perform(context.Background(), m)
+	if result.IsUp {
+		t.Error("Expected the denied network to win over the allowed host")
+	}
+	if result.ErrorCategory != models.ErrorBlocked {
+		t.Errorf("Expected a blocked check, got %q: %s", result.ErrorCategory, result.ErrorMessage)
+	}
+
+	// Without the deny rule the allowed host may reach loopback.
+	p, _ = checker.ParsePolicy("localhost", "")
+	checker.SetPolicy(p)
+	if result := checker.Perform(context.Background(), m); !result.IsUp {
+		t.Errorf("Expected allowed host to reach loopback, got %s", result.ErrorMessage)
+	}
+}
+
+func TestCheckFailureCategories(t *testing.T) {
+	allowLoopback(t)
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	closedAddr := closed.Addr().String()
+	closed.Close()
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(1500 * time.Millisecond)
+	}))
+	defer slow.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	resolver := startStubDNS(t, net.ParseIP("192.0.2.10"))
+
+	tests := []struct {
+		name    string
+		monitor models.Monitor
+		want    string
+	}{
+		{"Refused", models.Monitor{Type: models.MonitorTypeTCP, URL: closedAddr}, models.ErrorRefused},
+		{"Timeout", models.Monitor{Type: models.MonitorTypeHTTP, URL: slow.URL, Timeout: 1}, models.ErrorTimeout},
+		{"Status", models.Monitor{Type: models.MonitorTypeHTTP, URL: failing.URL}, models.ErrorHTTPStatus},
+		{"Blocked", models.Monitor{Type: models.MonitorTypeHTTP, URL: "http://10.255.255.1/"}, models.ErrorBlocked},
+		{"DNS", models.Monitor{Type: models.MonitorTypeDNS, URL: "missing.example.test", DNSRecordType: "TXT", DNSResolver: resolver}, models.ErrorDNS},
+		{"Heartbeat", models.Monitor{Type: models.MonitorTypePush, Interval: 60}, models.ErrorHeartbeat},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			result := checker.Perform(context.Background(), tc.monitor)
+			if result.IsUp {
+				t.Fatal("Expected check to fail")
+			}
+			if result.ErrorCategory != tc.want {
+				t.Errorf("Expected category %q, got %q (%s)", tc.want, result.ErrorCategory, result.ErrorMessage)
+			}
+			if result.ErrorMessage == "" {
+				t.Error("Expected an error message")
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"go-sentinel/internal/api"
+	"go-sentinel/internal/db"
+	"go-sentinel/internal/models"
+	"go-sentinel/internal/service/notifier"
+)
+
+func getDeliveries(t *testing.T, s *api.Server, webhookID int64) []models.Delivery {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/webhooks/"+strconv.FormatInt(webhookID, 10)+"/deliveries", nil)
+	req.Header.Set("Authorization", "secret")
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var deliveries []models.Delivery
+	if err := json.NewDecoder(w.Body).Decode(&deliveries); err != nil {
+		t.Fatalf("Invalid deliveries response: %v", err)
+	}
+	return deliveries
+}
+
+func TestNotifier_DeliveryRetries(t *testing.T) {
+	dbConn, err := db.InitializeInMem()
+	if err != nil {
+		t.Fatalf("Failed to init in-mem db: %v", err)
+	}
+	defer dbConn.Close()
+
+	s := api.NewServer(dbConn, "1.0.0")
+	s.AdminToken = "secret"
+
+	// /flaky is rate limited once, /rejected always refuses the payload.
+	var mu sync.Mutex
+	calls := map[string]int{}
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls[r.URL.Path]++
+		n := calls[r.URL.Path]
+		mu.Unlock()
+		switch {
+		case r.URL.Path == "/rejected":
+			http.Error(w, "bad payload", http.StatusBadRequest)
+		case n == 1:
+			w.Header().Set("Retry-After", "120")
+			http.Error(w, "slow down", http.StatusServiceUnavailable)
+		}
+	}))
+	defer stub.Close()
+
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+
+	ctx := context.Background()
+	ids := map[string]int64{}
+	for name, url := range map[string]string{
+		"flaky":       stub.URL + "/flaky",
+		"rejected":    stub.URL + "/rejected",
+		"unreachable": unreachable.URL,
+	} {
+		id, err := db.CreateWebhook(ctx, dbConn, models.Webhook{Name: name, Type: models.WebhookTypeDiscord, URL: url, Enabled: true})
+		if err != nil {
+			t.Fatalf("Failed to create webhook: %v", err)
+		}
+		ids[name] = id
+	}
+
+	m := models.Monitor{ID: 1, Name: "Shop", Type: models.MonitorTypeHTTP, URL: "https://shop.example.com", Interval: 60}
+	check := models.Check{MonitorID: 1, StatusCode: 503, ErrorCategory: models.ErrorHTTPStatus}
+	sent := time.Now()
+	notifier.NotifyStateChange(ctx, dbConn, m, check, "", time.Time{}, nil)
+	waitForNotifications(t)
+
+	t.Run("RetryAfter", func(t *testing.T) {
+		deliveries := getDeliveries(t, s, ids["flaky"])
+		if len(deliveries) != 1 {
+			t.Fatalf("Expected 1 delivery, got %d", len(deliveries))
+		}
+		d := deliveries[0]
+		if d.Status != models.DeliveryPending || d.Event != "down" || d.MonitorID != 1 {
+			t.Errorf("Unexpected delivery %+v", d)
+		}
+		if len(d.Attempts) != 1 || d.Attempts[0].StatusCode != 503 || d.Attempts[0].Error == "" {
+			t.Errorf("Expected one failed 503 attempt, got %+v", d.Attempts)
+		}
+		if d.NextAttemptAt == nil || d.NextAttemptAt.Before(sent.Add(120*time.Second)) {
+			t.Errorf("Expected the retry after Retry-After, got %v", d.NextAttemptAt)
+		}
+	})
+
+	t.Run("ClientErrorIsPermanent", func(t *testing.T) {
+		d := getDeliveries(t, s, ids["rejected"])[0]
+		if d.Status != models.DeliveryFailed || d.LastStatusCode != 400 || len(d.Attempts) != 1 {
+			t.Errorf("Expected a failed delivery after one 400, got %+v", d)
+		}
+	})
+
+	t.Run("NetworkErrorRetries", func(t *testing.T) {
+		d := getDeliveries(t, s, ids["unreachable"])[0]
+		if d.Status != models.DeliveryPending || len(d.Attempts) != 1 || d.Attempts[0].StatusCode != 0 || d.Attempts[0].Error == "" {
+			t.Errorf("Expected a pending delivery after a network error, got %+v", d)
+		}
+	})
+
+	// Nothing is due yet.
+	notifier.RetryDue(ctx, dbConn, time.Now())
+	waitForNotifications(t)
+	if d := getDeliveries(t, s, ids["flaky"])[0]; len(d.Attempts) != 1 {
+		t.Fatalf("Retried before the delay: %+v", d.Attempts)
+	}
+
+	notifier.RetryDue(ctx, dbConn, time.Now().Add(time.Hour))
+	waitForNotifications(t)
+
+	t.Run("Retried", func(t *testing.T) {
+		d := getDeliveries(t, s, ids["flaky"])[0]
+		if d.Status != models.DeliveryDelivered || d.NextAttemptAt != nil {
+			t.Errorf("Expected the delivery to succeed on retry, got %+v", d)
+		}
+		if len(d.Attempts) != 2 || d.Attempts[1].StatusCode != 200 || d.Attempts[1].Error != "" {
+			t.Errorf("Expected a successful second attempt, got %+v", d.Attempts)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if calls["/rejected"] != 1 {
+			t.Errorf("Failed delivery was retried: %d calls", calls["/rejected"])
+		}
+	})
+
+	t.Run("API_Errors", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/webhooks/999/deliveries", nil)
+		req.Header.Set("Authorization", "secret")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusNotFound {
+			t.Errorf("Expected 404 for unknown webhook, got %d", w.Code)
+		}
+
+		req = httptest.NewRequest("GET", "/webhooks/"+strconv.FormatInt(ids["flaky"], 10)+"/deliveries", nil)
+		w = httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Expected 401 without a token, got %d", w.Code)
+		}
+	})
+}
+
+func TestNotifier_NewerEventSupersedesRetry(t *testing.T) {
+	dbConn, err := db.InitializeInMem()
+	if err != nil {
+		t.Fatalf("Failed to init in-mem db: %v", err)
+	}
+	defer dbConn.Close()
+
+	s := api.NewServer(dbConn, "1.0.0")
+	s.AdminToken = "secret"
+
+	// The first request is rate limited, later ones go through.
+	var mu sync.Mutex
+	var titles []string
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Embeds []struct {
+				Title string `json:"title"`
+			} `json:"embeds"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		defer mu.Unlock()
+		titles = append(titles, body.Embeds[0].Title)
+		if len(titles) == 1 {
+			http.Error(w, "slow down", http.StatusTooManyRequests)
+		}
+	}))
+	defer stub.Close()
+
+	ctx := context.Background()
+	id, err := db.CreateWebhook(ctx, dbConn, models.Webhook{Name: "Discord", Type: models.WebhookTypeDiscord, URL: stub.URL, Enabled: true})
+	if err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+
+	m := models.Monitor{ID: 1, Name: "Shop", Type: models.MonitorTypeHTTP, URL: "https://shop.example.com", Interval: 60}
+	notifier.NotifyStateChange(ctx, dbConn, m, models.Check{MonitorID: 1}, "", time.Time{}, nil)
+	waitForNotifications(t)
+	notifier.NotifyStateChange(ctx, dbConn, m, models.Check{MonitorID: 1, IsUp: true}, models.StatusDown, time.Now(), nil)
+	waitForNotifications(t)
+	notifier.RetryDue(ctx, dbConn, time.Now().Add(time.Hour))
+	waitForNotifications(t)
+
+	deliveries := getDeliveries(t, s, id)
+	if len(deliveries) != 2 {
+		t.Fatalf("Expected 2 deliveries, got %d", len(deliveries))
+	}
+	if d := deliveries[0]; d.Event != "recovery" || d.Status != models.DeliveryDelivered {
+		t.Errorf("Expected the recovery to be delivered, got %+v", d)
+	}
+	if d := deliveries[1]; d.Event != "down" || d.Status != models.DeliverySuperseded || d.NextAttemptAt != nil {
+		t.Errorf("Expected the down alert to be superseded, got %+v", d)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(titles) != 2 {
+		t.Errorf("Expected the stale down alert not to be retried, got %q", titles)
+	}
+}
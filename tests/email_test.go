@@ -0,0 +1,281 @@
+package tests
+
+import (
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-sentinel/internal/db"
+	"go-sentinel/internal/models"
+	"go-sentinel/internal/service/notifier"
+)
+
+type smtpMail struct {
+	from string
+	to   []string
+	auth string
+	data string
+}
+
+// fakeSMTP is a minimal SMTP server that accepts every message. It offers
+// AUTH PLAIN but not STARTTLS.
+type fakeSMTP struct {
+	listener net.Listener
+	mu       sync.Mutex
+	mails    []smtpMail
+}
+
+func startFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	srv := &fakeSMTP{listener: l}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go srv.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { l.Close() })
+	return srv
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	tp.PrintfLine("220 fake ESMTP")
+
+	var m smtpMail
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			tp.PrintfLine("250-fake\r\n250-AUTH PLAIN\r\n250 8BITMIME")
+		case "AUTH":
+			_, encoded, _ := strings.Cut(arg, " ")
+			decoded, _ := base64.StdEncoding.DecodeString(encoded)
+			m.auth = string(decoded)
+			tp.PrintfLine("235 Authenticated")
+		case "MAIL":
+			m.from = smtpPath(strings.TrimPrefix(arg, "FROM:"))
+			tp.PrintfLine("250 OK")
+		case "RCPT":
+			m.to = append(m.to, smtpPath(strings.TrimPrefix(arg, "TO:")))
+			tp.PrintfLine("250 OK")
+		case "DATA":
+			tp.PrintfLine("354 Go ahead")
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			m.data = string(data)
+			s.mu.Lock()
+			s.mails = append(s.mails, m)
+			s.mu.Unlock()
+			m = smtpMail{auth: m.auth}
+			tp.PrintfLine("250 Queued")
+		case "QUIT":
+			tp.PrintfLine("221 Bye")
+			return
+		default:
+			tp.PrintfLine("502 Not implemented")
+		}
+	}
+}
+
+// smtpPath returns the address of a MAIL or RCPT argument, dropping any
+// ESMTP parameters after it.
+func smtpPath(arg string) string {
+	path, _, _ := strings.Cut(arg, " ")
+	return strings.Trim(path, "<>")
+}
+
+func (s *fakeSMTP) port() string {
+	_, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return port
+}
+
+func (s *fakeSMTP) received() []smtpMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]smtpMail(nil), s.mails...)
+}
+
+// readEmail parses a message and returns its subject and bodies by
+// content type.
+func readEmail(t *testing.T, data string) (string, map[string]string) {
+	t.Helper()
+	msg, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Invalid message: %v", err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("Invalid subject: %v", err)
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Expected multipart/alternative, got %q", msg.Header.Get("Content-Type"))
+	}
+
+	bodies := map[string]string{}
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Invalid part: %v", err)
+		}
+		body, _ := io.ReadAll(part) // NextPart decodes quoted-printable
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		bodies[contentType] = string(body)
+	}
+	return subject, bodies
+}
+
+func TestNotifier_Email(t *testing.T) {
+	dbConn, err := db.InitializeInMem()
+	if err != nil {
+		t.Fatalf("Failed to init in-mem db: %v", err)
+	}
+	defer dbConn.Close()
+
+	smtpServer := startFakeSMTP(t)
+	ctx := context.Background()
+	channel := models.Webhook{Name: "Ops", Type: models.WebhookTypeEmail, Enabled: true, Settings: map[string]string{
+		"host": "127.0.0.1", "port": smtpServer.port(), "starttls": "false",
+		"username": "alerts", "password": "hunter2",
+		"from": "Sentinel <sentinel@example.com>", "to": "ops@example.com, Support <support@example.com>",
+	}}
+	if err := channel.Validate(); err != nil {
+		t.Fatalf("Expected valid channel, got %v", err)
+	}
+	if _, err := db.CreateWebhook(ctx, dbConn, channel); err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+
+	m := models.Monitor{ID: 1, Name: "Shop", Type: models.MonitorTypeHTTP, URL: "https://shop.example.com", Interval: 60}
+	notifier.NotifyStateChange(ctx, dbConn, m, models.Check{MonitorID: 1, StatusCode: 500, ErrorCategory: models.ErrorHTTPStatus}, "", time.Time{}, nil)
+	waitForNotifications(t)
+	notifier.NotifyStateChange(ctx, dbConn, m, models.Check{MonitorID: 1, StatusCode: 200, IsUp: true}, "", time.Time{}, nil)
+	waitForNotifications(t)
+	cert := models.Certificate{MonitorID: 1, Issuer: "Test CA", NotAfter: time.Now().Add(72 * time.Hour)}
+	notifier.NotifyCertificateExpiry(ctx, dbConn, m, cert, 3)
+	waitForNotifications(t)
+
+	mails := smtpServer.received()
+	if len(mails) != 3 {
+		t.Fatalf("Expected 3 emails, got %d", len(mails))
+	}
+
+	first := mails[0]
+	if first.from != "sentinel@example.com" {
+		t.Errorf("Unexpected sender %q", first.from)
+	}
+	if strings.Join(first.to, ",") != "ops@example.com,support@example.com" {
+		t.Errorf("Unexpected recipients %v", first.to)
+	}
+	if first.auth != "\x00alerts\x00hunter2" {
+		t.Errorf("Expected PLAIN credentials, got %q", first.auth)
+	}
+
+	for i, want := range []string{"Monitor Down: Shop", "Monitor Recovered: Shop", "Certificate Expiring: Shop"} {
+		subject, bodies := readEmail(t, mails[i].data)
+		if !strings.Contains(subject, want) {
+			t.Errorf("Expected subject containing %q, got %q", want, subject)
+		}
+		if !strings.Contains(bodies["text/plain"], want) || strings.Contains(bodies["text/plain"], "**") {
+			t.Errorf("Unexpected text body %q", bodies["text/plain"])
+		}
+		if !strings.Contains(bodies["text/html"], "<strong>Shop</strong>") {
+			t.Errorf("Expected bold monitor name in HTML body, got %q", bodies["text/html"])
+		}
+	}
+}
+
+func TestNotifier_EmailRequiresStartTLS(t *testing.T) {
+	dbConn, err := db.InitializeInMem()
+	if err != nil {
+		t.Fatalf("Failed to init in-mem db: %v", err)
+	}
+	defer dbConn.Close()
+
+	smtpServer := startFakeSMTP(t)
+	ctx := context.Background()
+	if _, err := db.CreateWebhook(ctx, dbConn, models.Webhook{Name: "Ops", Type: models.WebhookTypeEmail, Enabled: true, Settings: map[string]string{
+		"host": "127.0.0.1", "port": smtpServer.port(), "from": "sentinel@example.com", "to": "ops@example.com",
+	}}); err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+
+	m := models.Monitor{ID: 1, Name: "Shop", Type: models.MonitorTypeHTTP, URL: "https://shop.example.com", Interval: 60}
+	notifier.NotifyStateChange(ctx, dbConn, m, models.Check{MonitorID: 1}, "", time.Time{}, nil)
+	waitForNotifications(t)
+
+	if mails := smtpServer.received(); len(mails) != 0 {
+		t.Errorf("Expected no email without STARTTLS, got %d", len(mails))
+	}
+}
+
+func TestWebhook_ValidateEmail(t *testing.T) {
+	valid := map[string]string{"host": "smtp.example.com", "from": "a@example.com", "to": "b@example.com"}
+	tests := []struct {
+		name    string
+		url     string
+		change  map[string]string
+		wantErr bool
+	}{
+		{"valid", "", nil, false},
+		{"url", "https://example.com", nil, true},
+		{"missing host", "", map[string]string{"host": ""}, true},
+		{"bad port", "", map[string]string{"port": "99999"}, true},
+		{"bad starttls", "", map[string]string{"starttls": "maybe"}, true},
+		{"password without user", "", map[string]string{"password": "x"}, true},
+		{"bad from", "", map[string]string{"from": "nobody"}, true},
+		{"no recipients", "", map[string]string{"to": ""}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			settings := map[string]string{}
+			for k, v := range valid {
+				settings[k] = v
+			}
+			for k, v := range tt.change {
+				settings[k] = v
+			}
+			wh := models.Webhook{Name: "Mail", Type: models.WebhookTypeEmail, URL: tt.url, Settings: settings}
+			if err := wh.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	settings := map[string]string{"starttls": "0"}
+	for k, v := range valid {
+		settings[k] = v
+	}
+	wh := models.Webhook{Name: "Mail", Type: models.WebhookTypeEmail, Settings: settings}
+	if err := wh.Validate(); err != nil || wh.Settings["starttls"] != "false" {
+		t.Errorf("Expected starttls 0 to be stored as false, got %q (%v)", wh.Settings["starttls"], err)
+	}
+}
@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"go-sentinel/internal/models"
+)
+
+func TestMaintenanceWindow_ActiveAt(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatalf("Failed to load time zone: %v", err)
+	}
+	start := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+
+	oneOff := models.MaintenanceWindow{StartsAt: &start, EndsAt: &end}
+	// Saturdays 23:00 Berlin time for two hours, crossing midnight.
+	weekly := models.MaintenanceWindow{Timezone: "Europe/Berlin", Weekdays: []int{6}, StartTime: "23:00", Duration: 120}
+
+	tests := []struct {
+		name   string
+		window models.MaintenanceWindow
+		at     time.Time
+		want   bool
+	}{
+		{"one-off before", oneOff, start.Add(-time.Minute), false},
+		{"one-off start", oneOff, start, true},
+		{"one-off inside", oneOff, start.Add(time.Hour), true},
+		{"one-off end", oneOff, end, false},
+		{"weekly before", weekly, time.Date(2025, 3, 1, 22, 59, 0, 0, berlin), false},
+		{"weekly start", weekly, time.Date(2025, 3, 1, 23, 0, 0, 0, berlin), true},
+		{"weekly after midnight", weekly, time.Date(2025, 3, 2, 0, 30, 0, 0, berlin), true},
+		{"weekly in UTC", weekly, time.Date(2025, 3, 1, 22, 30, 0, 0, time.UTC), true},
+		{"weekly end", weekly, time.Date(2025, 3, 2, 1, 0, 0, 0, berlin), false},
+		{"weekly other day", weekly, time.Date(2025, 3, 5, 23, 30, 0, 0, berlin), false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.window.ActiveAt(tc.at); got != tc.want {
+				t.Errorf("ActiveAt(%v) = %v, want %v", tc.at, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMaintenanceWindow_Validate(t *testing.T) {
+	start := time.Now()
+	end := start.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		window  models.MaintenanceWindow
+		wantErr bool
+	}{
+		{"one-off", models.MaintenanceWindow{Title: "Patch", StartsAt: &start, EndsAt: &end, MonitorIDs: []int64{1}}, false},
+		{"weekly", models.MaintenanceWindow{Title: "Patch", Timezone: "America/New_York", Weekdays: []int{0, 3}, StartTime: "02:30", Duration: 60, MonitorIDs: []int64{1}}, false},
+		{"no monitors", models.MaintenanceWindow{Title: "Patch", StartsAt: &start, EndsAt: &end}, true},
+		{"ends before start", models.MaintenanceWindow{Title: "Patch", StartsAt: &end, EndsAt: &start, MonitorIDs: []int64{1}}, true},
+		{"mixed schedule", models.MaintenanceWindow{Title: "Patch", StartsAt: &start, EndsAt: &end, Weekdays: []int{1}, StartTime: "02:00", Duration: 60, MonitorIDs: []int64{1}}, true},
+		{"bad weekday", models.MaintenanceWindow{Title: "Patch", Weekdays: []int{7}, StartTime: "02:00", Duration: 60, MonitorIDs: []int64{1}}, true},
+		{"bad start time", models.MaintenanceWindow{Title: "Patch", Weekdays: []int{1}, StartTime: "2am", Duration: 60, MonitorIDs: []int64{1}}, true},
+		{"bad time zone", models.MaintenanceWindow{Title: "Patch", Timezone: "Mars/Olympus", StartsAt: &start, EndsAt: &end, MonitorIDs: []int64{1}}, true},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.window.Validate()
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
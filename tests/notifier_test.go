@@ -0,0 +1,318 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-sentinel/internal/db"
+	"go-sentinel/internal/models"
+	"go-sentinel/internal/service/notifier"
+)
+
+// webhookRecorder stands in for a chat service and keeps every request it
+// receives.
+type webhookRecorder struct {
+	*httptest.Server
+	mu       sync.Mutex
+	requests map[string]map[string]any // path -> decoded JSON body
+}
+
+func newWebhookRecorder(t *testing.T) *webhookRecorder {
+	t.Helper()
+	rec := &webhookRecorder{requests: map[string]map[string]any{}}
+	rec.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("Invalid JSON posted to %s: %v", r.URL.Path, err)
+		}
+		rec.mu.Lock()
+		rec.requests[r.URL.Path] = body
+		rec.mu.Unlock()
+	}))
+	t.Cleanup(rec.Close)
+	return rec
+}
+
+func (rec *webhookRecorder) body(t *testing.T, path string) map[string]any {
+	t.Helper()
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	body, ok := rec.requests[path]
+	if !ok {
+		t.Fatalf("Nothing was posted to %s", path)
+	}
+	return body
+}
+
+func waitForNotifications(t *testing.T) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := notifier.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestNotifier_Providers(t *testing.T) {
+	dbConn, err := db.InitializeInMem()
+	if err != nil {
+		t.Fatalf("Failed to init in-mem db: %v", err)
+	}
+	defer dbConn.Close()
+
+	rec := newWebhookRecorder(t)
+	ctx := context.Background()
+	for _, wh := range []models.Webhook{
+		{Name: "Discord", Type: models.WebhookTypeDiscord, URL: rec.URL + "/discord"},
+		{Name: "Slack", Type: models.WebhookTypeSlack, URL: rec.URL + "/slack"},
+		{Name: "Teams", Type: models.WebhookTypeTeams, URL: rec.URL + "/teams"},
+		{Name: "Telegram", Type: models.WebhookTypeTelegram, URL: rec.URL, Settings: map[string]string{"bot_token": "123:abc", "chat_id": "-100"}},
+		{Name: "Disabled", Type: models.WebhookTypeSlack, URL: rec.URL + "/disabled"},
+	} {
+		wh.Enabled = wh.Name != "Disabled"
+		if _, err := db.CreateWebhook(ctx, dbConn, wh); err != nil {
+			t.Fatalf("Failed to create webhook: %v", err)
+		}
+	}
+
+	m := models.Monitor{ID: 1, Name: "Shop <prod>", Type: models.MonitorTypeHTTP, URL: "https://shop.example.com", Interval: 60}
+	check := models.Check{MonitorID: 1, StatusCode: 503, ErrorCategory: models.ErrorHTTPStatus, ErrorMessage: "unexpected status 503 Service Unavailable"}
+	notifier.NotifyStateChange(ctx, dbConn, m, check, "", time.Time{}, []string{"Checkout"})
+	waitForNotifications(t)
+
+	t.Run("Discord", func(t *testing.T) {
+		embed := rec.body(t, "/discord")["embeds"].([]any)[0].(map[string]any)
+		if !strings.Contains(embed["title"].(string), "Monitor Down: Shop <prod>") {
+			t.Errorf("Unexpected title %q", embed["title"])
+		}
+	})
+
+	t.Run("Slack", func(t *testing.T) {
+		body := rec.body(t, "/slack")
+		attachment := body["attachments"].([]any)[0].(map[string]any)
+		if attachment["color"] != "#E74C3C" {
+			t.Errorf("Expected red attachment, got %v", attachment["color"])
+		}
+		blocks := attachment["blocks"].([]any)
+		if blocks[0].(map[string]any)["type"] != "header" {
+			t.Errorf("Expected a header block first, got %v", blocks[0])
+		}
+		text := blocks[1].(map[string]any)["text"].(map[string]any)["text"].(string)
+		if !strings.HasPrefix(text, "*Shop &lt;prod&gt;*") {
+			t.Errorf("Expected escaped mrkdwn description, got %q", text)
+		}
+	})
+
+	t.Run("Teams", func(t *testing.T) {
+		body := rec.body(t, "/teams")
+		attachment := body["attachments"].([]any)[0].(map[string]any)
+		if attachment["contentType"] != "application/vnd.microsoft.card.adaptive" {
+			t.Errorf("Unexpected content type %v", attachment["contentType"])
+		}
+		card := attachment["content"].(map[string]any)
+		facts := card["body"].([]any)[2].(map[string]any)["facts"].([]any)
+		found := false
+		for _, f := range facts {
+			if f.(map[string]any)["title"] == "Affected Monitors" {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected affected monitors in %v", facts)
+		}
+	})
+
+	t.Run("Telegram", func(t *testing.T) {
+		body := rec.body(t, "/bot123:abc/sendMessage")
+		if body["chat_id"] != "-100" || body["parse_mode"] != "HTML" {
+			t.Errorf("Unexpected payload %v", body)
+		}
+		if text := body["text"].(string); !strings.Contains(text, "<b>Shop &lt;prod&gt;</b>") {
+			t.Errorf("Expected escaped HTML text, got %q", text)
+		}
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+		if _, ok := rec.requests["/disabled"]; ok {
+			t.Error("Expected disabled webhook not to be called")
+		}
+	})
+}
+
+type recordedRequest struct {
+	method, path, query, auth string
+	body                      map[string]any
+}
+
+func TestNotifier_IncidentChannels(t *testing.T) {
+	dbConn, err := db.InitializeInMem()
+	if err != nil {
+		t.Fatalf("Failed to init in-mem db: %v", err)
+	}
+	defer dbConn.Close()
+
+	var mu sync.Mutex
+	var requests []recordedRequest
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		requests = append(requests, recordedRequest{r.Method, r.URL.Path, r.URL.RawQuery, r.Header.Get("Authorization"), body})
+		mu.Unlock()
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer stub.Close()
+
+	ctx := context.Background()
+	for _, wh := range []models.Webhook{
+		{Name: "PagerDuty", Type: models.WebhookTypePagerDuty, URL: stub.URL + "/v2/enqueue", Settings: map[string]string{"routing_key": "R0UT1NG"}},
+		{Name: "Opsgenie", Type: models.WebhookTypeOpsgenie, URL: stub.URL, Settings: map[string]string{"api_key": "genie"}},
+	} {
+		wh.Enabled = true
+		if err := wh.Validate(); err != nil {
+			t.Fatalf("Expected valid channel, got %v", err)
+		}
+		if _, err := db.CreateWebhook(ctx, dbConn, wh); err != nil {
+			t.Fatalf("Failed to create webhook: %v", err)
+		}
+	}
+
+	// takeRequests returns the requests received since the last call,
+	// keyed by the channel that made them.
+	takeRequests := func(t *testing.T) (pagerDuty, opsgenie recordedRequest) {
+		t.Helper()
+		waitForNotifications(t)
+		mu.Lock()
+		defer mu.Unlock()
+		if len(requests) != 2 {
+			t.Fatalf("Expected one request per channel, got %+v", requests)
+		}
+		for _, r := range requests {
+			if r.path == "/v2/enqueue" {
+				pagerDuty = r
+			} else {
+				opsgenie = r
+			}
+		}
+		requests = nil
+		return pagerDuty, opsgenie
+	}
+
+	m := models.Monitor{ID: 7, Name: "Checkout", Type: models.MonitorTypeHTTP, URL: "https://checkout.example.com", Interval: 60}
+	notifier.NotifyStateChange(ctx, dbConn, m, models.Check{MonitorID: 7, StatusCode: 502, ErrorCategory: models.ErrorHTTPStatus}, "", time.Time{}, nil)
+	pd, og := takeRequests(t)
+
+	if pd.body["event_action"] != "trigger" || pd.body["routing_key"] != "R0UT1NG" {
+		t.Errorf("Expected a PagerDuty trigger, got %v", pd.body)
+	}
+	payload, _ := pd.body["payload"].(map[string]any)
+	if payload["severity"] != "critical" || !strings.Contains(payload["summary"].(string), "Checkout") {
+		t.Errorf("Unexpected PagerDuty payload %v", payload)
+	}
+	triggerKey := pd.body["dedup_key"]
+	if og.method != http.MethodPost || og.path != "/v2/alerts" || og.auth != "GenieKey genie" {
+		t.Errorf("Unexpected Opsgenie create request %+v", og)
+	}
+	alias := og.body["alias"]
+
+	notifier.NotifyStateChange(ctx, dbConn, m, models.Check{MonitorID: 7, StatusCode: 200, IsUp: true}, "", time.Time{}, nil)
+	pd, og = takeRequests(t)
+
+	if pd.body["event_action"] != "resolve" || pd.body["dedup_key"] != triggerKey {
+		t.Errorf("Expected a resolve for %v, got %v", triggerKey, pd.body)
+	}
+	if og.path != "/v2/alerts/"+alias.(string)+"/close" || og.query != "identifierType=alias" {
+		t.Errorf("Expected the Opsgenie alert %v to be closed, got %+v", alias, og)
+	}
+
+	notifier.NotifyCertificateExpiry(ctx, dbConn, m, models.Certificate{MonitorID: 7, NotAfter: time.Now()}, 1)
+	waitForNotifications(t)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requests) != 0 {
+		t.Errorf("Expected certificate warnings to be skipped, got %+v", requests)
+	}
+}
+
+func TestNotifier_GenericWebhook(t *testing.T) {
+	dbConn, err := db.InitializeInMem()
+	if err != nil {
+		t.Fatalf("Failed to init in-mem db: %v", err)
+	}
+	defer dbConn.Close()
+
+	type received struct {
+		method, contentType, token, body string
+	}
+	var mu sync.Mutex
+	got := map[string]received{}
+	stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		got[r.URL.Path] = received{r.Method, r.Header.Get("Content-Type"), r.Header.Get("X-Token"), string(body)}
+		mu.Unlock()
+	}))
+	defer stub.Close()
+
+	ctx := context.Background()
+	for _, wh := range []models.Webhook{
+		{
+			Name: "ntfy", Type: models.WebhookTypeGeneric, URL: stub.URL + "/templated",
+			Headers: map[string]string{"X-Token": "t0k3n", "Content-Type": "text/plain"},
+			Settings: map[string]string{
+				"method": "put",
+				"body":   `{{.Monitor.Name}} is {{if .Check.IsUp}}up{{else}}down{{end}} (was {{.PreviousState}} for {{.DownFor}}, {{.DownForSeconds}}s) {{json .Check.ErrorCategory}}`,
+			},
+		},
+		{Name: "Default", Type: models.WebhookTypeGeneric, URL: stub.URL + "/default"},
+	} {
+		wh.Enabled = true
+		if err := wh.Validate(); err != nil {
+			t.Fatalf("Expected valid channel, got %v", err)
+		}
+		if _, err := db.CreateWebhook(ctx, dbConn, wh); err != nil {
+			t.Fatalf("Failed to create webhook: %v", err)
+		}
+	}
+
+	m := models.Monitor{ID: 3, Name: "API", Type: models.MonitorTypeHTTP, URL: "https://api.example.com", Interval: 60,
+		Headers: map[string]string{"Authorization": "secret"}}
+	downSince := time.Now().Add(-90 * time.Second)
+	notifier.NotifyStateChange(ctx, dbConn, m, models.Check{MonitorID: 3, StatusCode: 200, IsUp: true}, models.StatusDown, downSince, nil)
+	waitForNotifications(t)
+
+	mu.Lock()
+	defer mu.Unlock()
+	templated := got["/templated"]
+	if templated.method != http.MethodPut || templated.token != "t0k3n" || templated.contentType != "text/plain" {
+		t.Errorf("Expected configured method and headers, got %+v", templated)
+	}
+	if !strings.HasPrefix(templated.body, "API is up (was down for 1m3") || !strings.Contains(templated.body, `s, 90s) ""`) {
+		t.Errorf("Unexpected templated body %q", templated.body)
+	}
+
+	var payload struct {
+		Event          string         `json:"event"`
+		PreviousState  string         `json:"previous_state"`
+		DownForSeconds int64          `json:"down_for_seconds"`
+		Monitor        models.Monitor `json:"monitor"`
+	}
+	if err := json.Unmarshal([]byte(got["/default"].body), &payload); err != nil {
+		t.Fatalf("Expected a JSON default body, got %q", got["/default"].body)
+	}
+	if payload.Event != "recovery" || payload.PreviousState != models.StatusDown || payload.DownForSeconds != 90 || payload.Monitor.Name != "API" {
+		t.Errorf("Unexpected default payload %+v", payload)
+	}
+	if payload.Monitor.Headers != nil {
+		t.Errorf("Expected monitor headers to be left out, got %v", payload.Monitor.Headers)
+	}
+}
@@ -0,0 +1,898 @@
+package tests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-sentinel/internal/api"
+	"go-sentinel/internal/db"
+	"go-sentinel/internal/models"
+	"go-sentinel/internal/service/monitor"
+	"go-sentinel/internal/service/notifier"
+)
+
+// startWorkerServer returns an API server backed by a fresh database and a
+// running worker. Monitors are checked within a second of being created.
+func startWorkerServer(t *testing.T, cfg monitor.Config) (*api.Server, *sql.DB) {
+	t.Helper()
+	allowLoopback(t)
+	dbConn, err := db.InitializeInMem()
+	if err != nil {
+		t.Fatalf("Failed to init in-mem db: %v", err)
+	}
+	dbConn.SetMaxOpenConns(1)
+
+	s := api.NewServer(dbConn, "1.0.0")
+	s.AdminToken = "secret"
+	s.Worker = monitor.StartWorker(dbConn, cfg)
+	t.Cleanup(func() {
+		s.Worker.Stop(time.Second)
+		dbConn.Close()
+	})
+	return s, dbConn
+}
+
+func createMonitor(t *testing.T, s *api.Server, m models.Monitor) models.Monitor {
+	t.Helper()
+	body, _ := json.Marshal(m)
+	req := httptest.NewRequest("POST", "/monitors", bytes.NewReader(body))
+	req.Header.Set("Authorization", "secret")
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created models.Monitor
+	json.NewDecoder(w.Body).Decode(&created)
+	return created
+}
+
+func getMetrics(t *testing.T, s *api.Server) monitor.Stats {
+	t.Helper()
+	req := httptest.NewRequest("GET", "/metrics", nil)
+	req.Header.Set("Authorization", "secret")
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+	var stats monitor.Stats
+	json.NewDecoder(w.Body).Decode(&stats)
+	return stats
+}
+
+func TestWorker_SchedulesCreatedMonitor(t *testing.T) {
+	hits := make(chan struct{}, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits <- struct{}{}
+	}))
+	defer srv.Close()
+
+	s, dbConn := startWorkerServer(t, monitor.Config{})
+	created := createMonitor(t, s, models.Monitor{Name: "Scheduled", URL: srv.URL, Interval: 10})
+	ctx := context.Background()
+
+	// Jitter is at most a tenth of the 10s interval.
+	select {
+	case <-hits:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Expected new monitor to be checked without waiting for a poll")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		checks, err := db.GetChecks(ctx, dbConn, 10)
+		if err != nil {
+			t.Fatalf("Failed to load checks: %v", err)
+		}
+		if len(checks[created.ID]) == 1 && checks[created.ID][0].IsUp {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected one successful check to be saved, got %+v", checks[created.ID])
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestWorker_PerHostLimit(t *testing.T) {
+	var mu sync.Mutex
+	var running, peak int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+		time.Sleep(300 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	s, _ := startWorkerServer(t, monitor.Config{Workers: 4, PerHostLimit: 1})
+	for i := range 3 {
+		createMonitor(t, s, models.Monitor{Name: fmt.Sprintf("Shared %d", i), URL: srv.URL, Interval: 10})
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for getMetrics(t, s).Completed < 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected 3 completed checks, got %+v", getMetrics(t, s))
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if peak != 1 {
+		t.Errorf("Expected at most 1 concurrent check per host, got %d", peak)
+	}
+}
+
+func TestWorker_BusyHostDoesNotBlockOthers(t *testing.T) {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	// A second loopback address counts as a different host.
+	ln, err := net.Listen("tcp", "127.0.0.2:0")
+	if err != nil {
+		t.Skipf("No second loopback address: %v", err)
+	}
+	fast := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	fast.Listener.Close()
+	fast.Listener = ln
+	fast.Start()
+	defer fast.Close()
+
+	s, _ := startWorkerServer(t, monitor.Config{Workers: 2, PerHostLimit: 1})
+	for i := range 2 {
+		createMonitor(t, s, models.Monitor{Name: fmt.Sprintf("Slow %d", i), URL: slow.URL, Interval: 10})
+	}
+	m := createMonitor(t, s, models.Monitor{Name: "Fast", URL: fast.URL, Interval: 10})
+	waitForStatus(t, s, m.ID, models.StatusUp)
+}
+
+func TestWorker_WaitingCheckTakesFreedSlot(t *testing.T) {
+	release := make(chan struct{})
+	arrived := make(chan string, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		arrived <- r.URL.Path
+		if r.URL.Path == "/hog" {
+			<-release
+		}
+	}))
+	defer srv.Close()
+
+	s, _ := startWorkerServer(t, monitor.Config{PerHostLimit: 1})
+	createMonitor(t, s, models.Monitor{Name: "Hog", URL: srv.URL + "/hog", Interval: 10})
+	if path := <-arrived; path != "/hog" {
+		t.Fatalf("Expected the hog to run first, got %s", path)
+	}
+	createMonitor(t, s, models.Monitor{Name: "Waiting", URL: srv.URL + "/waiting", Interval: 10})
+
+	select {
+	case path := <-arrived:
+		t.Fatalf("%s ran while the host was at its limit", path)
+	case <-time.After(time.Second):
+	}
+	close(release)
+	select {
+	case <-arrived:
+	case <-time.After(250 * time.Millisecond):
+		t.Fatal("Waiting check did not start once the slot was freed")
+	}
+}
+
+func TestWorker_DropsWhenQueueFull(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	s, _ := startWorkerServer(t, monitor.Config{Workers: 1, QueueSize: 1})
+	for i := range 3 {
+		createMonitor(t, s, models.Monitor{Name: fmt.Sprintf("Blocked %d", i), URL: srv.URL, Interval: 10})
+	}
+
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		stats := getMetrics(t, s)
+		if stats.Dropped >= 1 && stats.InFlight == 1 && stats.QueueDepth == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected one running, one queued and a dropped check, got %+v", stats)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func waitForInFlight(t *testing.T, s *api.Server) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for s.Worker.Stats().InFlight == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected a check to start")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestWorker_StopDrainsRunningChecks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(500 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	s, dbConn := startWorkerServer(t, monitor.Config{})
+	created := createMonitor(t, s, models.Monitor{Name: "Draining", URL: srv.URL, Interval: 10})
+	waitForInFlight(t, s)
+
+	if err := s.Worker.Stop(5 * time.Second); err != nil {
+		t.Fatalf("Expected clean stop, got %v", err)
+	}
+
+	checks, err := db.GetChecks(context.Background(), dbConn, 10)
+	if err != nil {
+		t.Fatalf("Failed to load checks: %v", err)
+	}
+	if len(checks[created.ID]) != 1 || !checks[created.ID][0].IsUp {
+		t.Errorf("Expected the running check to finish and be saved as up, got %+v", checks[created.ID])
+	}
+
+	// Events after shutdown must not block the API.
+	s.Worker.Notify(monitor.Event{Kind: monitor.EventUpdated, MonitorID: created.ID})
+}
+
+func TestWorker_StopDeadline(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	s, dbConn := startWorkerServer(t, monitor.Config{})
+	created := createMonitor(t, s, models.Monitor{Name: "Stuck", URL: srv.URL, Interval: 10})
+	waitForInFlight(t, s)
+
+	start := time.Now()
+	if err := s.Worker.Stop(200 * time.Millisecond); err == nil {
+		t.Error("Expected Stop to report the missed deadline")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected Stop to give up near its deadline, took %v", elapsed)
+	}
+
+	// The cancelled check is not recorded as a failure.
+	checks, err := db.GetChecks(context.Background(), dbConn, 10)
+	if err != nil {
+		t.Fatalf("Failed to load checks: %v", err)
+	}
+	if len(checks[created.ID]) != 0 {
+		t.Errorf("Expected the cancelled check not to be saved, got %+v", checks[created.ID])
+	}
+}
+
+func waitForStatus(t *testing.T, s *api.Server, id int64, status string) models.Monitor {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		req := httptest.NewRequest("GET", "/monitors", nil)
+		req.Header.Set("Authorization", "secret")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		var monitors []models.Monitor
+		json.NewDecoder(w.Body).Decode(&monitors)
+		for _, m := range monitors {
+			if m.ID == id && m.Status == status {
+				return m
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected monitor %d to become %q, got %+v", id, status, monitors)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestWorker_StatusSurvivesRestart(t *testing.T) {
+	var healthy atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	alerts := make(chan string, 4)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Embeds []struct {
+				Title string `json:"title"`
+			} `json:"embeds"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		if len(payload.Embeds) > 0 {
+			alerts <- payload.Embeds[0].Title
+		}
+	}))
+	defer hook.Close()
+
+	s, dbConn := startWorkerServer(t, monitor.Config{})
+	ctx := context.Background()
+	if _, err := db.CreateWebhook(ctx, dbConn, models.Webhook{Name: "Hook", URL: hook.URL, Enabled: true}); err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+
+	created := createMonitor(t, s, models.Monitor{Name: "Restarted", URL: srv.URL, Interval: 10})
+	down := waitForStatus(t, s, created.ID, models.StatusDown)
+	if down.StatusSince == nil || down.ConsecutiveFailures != 1 {
+		t.Errorf("Expected status_since and 1 consecutive failure, got %+v", down)
+	}
+	if err := s.Worker.Stop(5 * time.Second); err != nil {
+		t.Fatalf("Failed to stop worker: %v", err)
+	}
+
+	// Make the restarted worker check straight away instead of waiting out
+	// the interval.
+	if _, err := dbConn.Exec("UPDATE monitors SET last_checked_at = NULL"); err != nil {
+		t.Fatalf("Failed to reset last_checked_at: %v", err)
+	}
+	healthy.Store(true)
+	s.Worker = monitor.StartWorker(dbConn, monitor.Config{})
+	t.Cleanup(func() { s.Worker.Stop(time.Second) })
+
+	up := waitForStatus(t, s, created.ID, models.StatusUp)
+	if up.ConsecutiveFailures != 0 || !up.StatusSince.After(*down.StatusSince) {
+		t.Errorf("Expected failures reset and a newer status_since, got %+v", up)
+	}
+
+	select {
+	case title := <-alerts:
+		if !strings.Contains(title, "Recovered") {
+			t.Errorf("Expected a recovery alert after restart, got %q", title)
+		}
+	case <-time.After(3 * time.Second):
+		t.Error("Expected the first transition after a restart to notify")
+	}
+}
+
+func TestWorker_PauseResume(t *testing.T) {
+	hits := make(chan struct{}, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits <- struct{}{}
+	}))
+	defer srv.Close()
+
+	s, dbConn := startWorkerServer(t, monitor.Config{})
+	ctx := context.Background()
+	id, err := db.CreateMonitor(ctx, dbConn, models.Monitor{Type: models.MonitorTypeHTTP, Name: "Paused", URL: srv.URL, Interval: 10})
+	if err != nil {
+		t.Fatalf("Failed to create monitor: %v", err)
+	}
+	if _, err := db.SetMonitorActive(ctx, dbConn, id, false); err != nil {
+		t.Fatalf("Failed to pause monitor: %v", err)
+	}
+	s.Worker.Notify(monitor.Event{Kind: monitor.EventCreated, MonitorID: id})
+
+	select {
+	case <-hits:
+		t.Fatal("Expected paused monitor not to be checked")
+	case <-time.After(1500 * time.Millisecond):
+	}
+
+	req := httptest.NewRequest("POST", fmt.Sprintf("/monitors/%d/resume", id), nil)
+	req.Header.Set("Authorization", "secret")
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", w.Code)
+	}
+
+	select {
+	case <-hits:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Expected resumed monitor to be checked")
+	}
+}
+
+func TestWorker_MaintenanceWindow(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	s, dbConn := startWorkerServer(t, monitor.Config{})
+	ctx := context.Background()
+	id, err := db.CreateMonitor(ctx, dbConn, models.Monitor{Type: models.MonitorTypeHTTP, Name: "Patching", URL: srv.URL, Interval: 10})
+	if err != nil {
+		t.Fatalf("Failed to create monitor: %v", err)
+	}
+	start := time.Now().Add(-time.Minute)
+	end := time.Now().Add(time.Hour)
+	if _, err := db.CreateMaintenanceWindow(ctx, dbConn, models.MaintenanceWindow{
+		Title: "Patching", Timezone: "UTC", StartsAt: &start, EndsAt: &end, MonitorIDs: []int64{id},
+	}); err != nil {
+		t.Fatalf("Failed to create maintenance window: %v", err)
+	}
+	s.Worker.Notify(monitor.Event{Kind: monitor.EventCreated, MonitorID: id})
+
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		checks, err := db.GetChecks(ctx, dbConn, 10)
+		if err != nil {
+			t.Fatalf("Failed to load checks: %v", err)
+		}
+		if len(checks[id]) > 0 {
+			if c := checks[id][0]; !c.Maintenance || c.IsUp {
+				t.Errorf("Expected a failed check flagged as maintenance, got %+v", c)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("Expected the monitor to be checked during maintenance")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	m, err := db.GetMonitor(ctx, dbConn, id)
+	if err != nil {
+		t.Fatalf("Failed to load monitor: %v", err)
+	}
+	if m.Status != "" || m.ConsecutiveFailures != 0 {
+		t.Errorf("Expected status to be left alone during maintenance, got %q with %d failures", m.Status, m.ConsecutiveFailures)
+	}
+}
+
+func TestWorker_DependencySuppressesChildAlerts(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer healthy.Close()
+
+	type embed struct {
+		Title  string `json:"title"`
+		Fields []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"fields"`
+	}
+	alerts := make(chan embed, 10)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Embeds []embed `json:"embeds"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		for _, e := range payload.Embeds {
+			alerts <- e
+		}
+	}))
+	defer hook.Close()
+
+	s, dbConn := startWorkerServer(t, monitor.Config{})
+	ctx := context.Background()
+	if _, err := db.CreateWebhook(ctx, dbConn, models.Webhook{Name: "Hook", URL: hook.URL, Enabled: true}); err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+
+	// The monitors are stored with the worker stopped, so that it starts
+	// with their statuses rather than racing the writes.
+	if err := s.Worker.Stop(5 * time.Second); err != nil {
+		t.Fatalf("Failed to stop worker: %v", err)
+	}
+
+	// create stores a monitor with a previously confirmed status, as if
+	// the worker had already been running.
+	create := func(name, url string, parentID int64, status string) int64 {
+		t.Helper()
+		id, err := db.CreateMonitor(ctx, dbConn, models.Monitor{Type: models.MonitorTypeHTTP, Name: name, URL: url, Interval: 10, ParentID: parentID})
+		if err != nil {
+			t.Fatalf("Failed to create monitor: %v", err)
+		}
+		if err := db.UpdateMonitorStatus(ctx, dbConn, id, status, time.Now(), 0, false); err != nil {
+			t.Fatalf("Failed to set status: %v", err)
+		}
+		return id
+	}
+
+	// A child failing while its parent is down stays quiet.
+	router := create("Router", failing.URL, 0, models.StatusDown)
+	app := create("API", failing.URL, router, models.StatusUp)
+	// A parent going down alerts once, listing its dependents.
+	core := create("Core", failing.URL, 0, models.StatusUp)
+	create("Web", healthy.URL, core, models.StatusUp)
+
+	s.Worker = monitor.StartWorker(dbConn, monitor.Config{})
+	t.Cleanup(func() { s.Worker.Stop(time.Second) })
+
+	var got []embed
+	timeout := time.After(3 * time.Second)
+collect:
+	for {
+		select {
+		case e := <-alerts:
+			got = append(got, e)
+		case <-timeout:
+			break collect
+		}
+	}
+
+	if len(got) != 1 || !strings.Contains(got[0].Title, "Core") {
+		t.Fatalf("Expected a single alert for Core, got %+v", got)
+	}
+	affected := ""
+	for _, f := range got[0].Fields {
+		if f.Name == "Affected Monitors" {
+			affected = f.Value
+		}
+	}
+	if affected != "Web" {
+		t.Errorf("Expected Core's alert to list Web as affected, got %q", affected)
+	}
+
+	checks, err := db.GetChecks(ctx, dbConn, 10)
+	if err != nil {
+		t.Fatalf("Failed to load checks: %v", err)
+	}
+	if len(checks[app]) == 0 || checks[app][0].ErrorCategory != models.ErrorDependency {
+		t.Errorf("Expected API's failure to be recorded as %s, got %+v", models.ErrorDependency, checks[app])
+	}
+}
+
+func TestWorker_NewPushMonitorNotDown(t *testing.T) {
+	s, _ := startWorkerServer(t, monitor.Config{})
+	m := createMonitor(t, s, models.Monitor{Name: "Nightly", Type: models.MonitorTypePush, Interval: 3600})
+	if m.LastPushAt == nil {
+		t.Fatal("Expected the first heartbeat deadline to start at creation")
+	}
+
+	req := httptest.NewRequest("POST", fmt.Sprintf("/monitors/%d/check", m.ID), nil)
+	req.Header.Set("Authorization", "secret")
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	var result models.Check
+	json.NewDecoder(w.Body).Decode(&result)
+	if w.Code != http.StatusOK || !result.IsUp {
+		t.Errorf("Expected a new push monitor to be up before its first deadline, got %d: %+v", w.Code, result)
+	}
+}
+
+func TestWorker_CheckNow(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	s, dbConn := startWorkerServer(t, monitor.Config{})
+	ctx := context.Background()
+	id, err := db.CreateMonitor(ctx, dbConn, models.Monitor{Type: models.MonitorTypeHTTP, Name: "On demand", URL: srv.URL, Interval: 3600})
+	if err != nil {
+		t.Fatalf("Failed to create monitor: %v", err)
+	}
+
+	req := httptest.NewRequest("POST", fmt.Sprintf("/monitors/%d/check", id), nil)
+	req.Header.Set("Authorization", "secret")
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var result struct {
+		models.Check
+		StatusCode int `json:"status_code"`
+	}
+	json.NewDecoder(w.Body).Decode(&result)
+	if result.IsUp || result.StatusCode != http.StatusServiceUnavailable || result.ErrorCategory != models.ErrorHTTPStatus {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	checks, err := db.GetChecks(ctx, dbConn, 10)
+	if err != nil {
+		t.Fatalf("Failed to load checks: %v", err)
+	}
+	if len(checks[id]) != 1 {
+		t.Errorf("Expected the check to be saved, got %d checks", len(checks[id]))
+	}
+	m, err := db.GetMonitor(ctx, dbConn, id)
+	if err != nil || m == nil {
+		t.Fatalf("Failed to load monitor: %v", err)
+	}
+	if m.Status != models.StatusDown {
+		t.Errorf("Expected status %q, got %q", models.StatusDown, m.Status)
+	}
+
+	req = httptest.NewRequest("POST", "/monitors/999/check", nil)
+	req.Header.Set("Authorization", "secret")
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for unknown monitor, got %d", w.Code)
+	}
+
+	// Paused monitors are kept out of uptime, so they cannot be checked.
+	if _, err := db.SetMonitorActive(ctx, dbConn, id, false); err != nil {
+		t.Fatalf("Failed to pause monitor: %v", err)
+	}
+	req = httptest.NewRequest("POST", fmt.Sprintf("/monitors/%d/check", id), nil)
+	req.Header.Set("Authorization", "secret")
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 for a paused monitor, got %d", w.Code)
+	}
+	if checks, _ := db.GetChecks(ctx, dbConn, 10); len(checks[id]) != 1 {
+		t.Errorf("Expected no check to be saved for a paused monitor, got %d", len(checks[id]))
+	}
+}
+
+func TestWorker_CheckNowWaitsForScheduledCheck(t *testing.T) {
+	started := make(chan struct{}, 1)
+	var mu sync.Mutex
+	var running, peak int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		time.Sleep(300 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	s, _ := startWorkerServer(t, monitor.Config{})
+	m := createMonitor(t, s, models.Monitor{Name: "Busy", URL: srv.URL, Interval: 10})
+	select {
+	case <-started:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Scheduled check never started")
+	}
+
+	req := httptest.NewRequest("POST", fmt.Sprintf("/monitors/%d/check", m.ID), nil)
+	req.Header.Set("Authorization", "secret")
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if peak != 1 {
+		t.Errorf("Expected the manual check to wait for the scheduled one, got %d at once", peak)
+	}
+}
+
+func TestWorker_CheckNowGivesUpWhenHostBusy(t *testing.T) {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	s, _ := startWorkerServer(t, monitor.Config{PerHostLimit: 1})
+	createMonitor(t, s, models.Monitor{Name: "Hog", URL: srv.URL, Interval: 10})
+	select {
+	case <-started:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Scheduled check never started")
+	}
+
+	m := createMonitor(t, s, models.Monitor{Name: "Waiting", URL: srv.URL, Interval: 10, Timeout: 1})
+	req := httptest.NewRequest("POST", fmt.Sprintf("/monitors/%d/check", m.ID), nil)
+	req.Header.Set("Authorization", "secret")
+	w := httptest.NewRecorder()
+	start := time.Now()
+	s.ServeHTTP(w, req)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected 503, got %d: %s", w.Code, w.Body.String())
+	}
+	if waited := time.Since(start); waited > 3*time.Second {
+		t.Errorf("Expected to give up after the 1s timeout, waited %s", waited)
+	}
+}
+
+func TestWorker_SlowCheckOutlastsWriteTimeout(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(11 * time.Second)
+	}))
+	defer target.Close()
+
+	s, _ := startWorkerServer(t, monitor.Config{})
+	// Served with the production write timeout. Clients asking for gzip get
+	// a wrapped response writer whose deadline must still be extended.
+	srv := httptest.NewUnstartedServer(s)
+	srv.Config.WriteTimeout = 10 * time.Second
+	srv.Start()
+	defer srv.Close()
+
+	body := fmt.Sprintf(`{"name":"Slow","url":%q,"interval":60,"timeout":15}`, target.URL)
+	req, _ := http.NewRequest("POST", srv.URL+"/monitors/test", strings.NewReader(body))
+	req.Header.Set("Authorization", "secret")
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected 200, got %d", resp.StatusCode)
+	}
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatalf("Expected a gzip response: %v", err)
+	}
+	var check models.Check
+	if err := json.NewDecoder(gz).Decode(&check); err != nil {
+		t.Fatalf("Response cut off: %v", err)
+	}
+	if !check.IsUp {
+		t.Errorf("Expected the slow check to pass, got %+v", check)
+	}
+}
+
+func TestWorker_TestMonitorConfig(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer srv.Close()
+
+	s, dbConn := startWorkerServer(t, monitor.Config{})
+	tests := []struct {
+		name string
+		body string
+		code int
+		isUp bool
+	}{
+		{"passing", fmt.Sprintf(`{"name":"Try","url":%q,"interval":60,"json_assertions":["$.status == \"ok\""]}`, srv.URL), http.StatusOK, true},
+		{"failing assertion", fmt.Sprintf(`{"name":"Try","url":%q,"interval":60,"keyword":"missing"}`, srv.URL), http.StatusOK, false},
+		{"invalid", `{"name":"Try","url":"ftp://example.com","interval":60}`, http.StatusBadRequest, false},
+		{"push", `{"name":"Try","type":"push","interval":60}`, http.StatusBadRequest, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/monitors/test", strings.NewReader(tt.body))
+			req.Header.Set("Authorization", "secret")
+			w := httptest.NewRecorder()
+			s.ServeHTTP(w, req)
+			if w.Code != tt.code {
+				t.Fatalf("Expected %d, got %d: %s", tt.code, w.Code, w.Body.String())
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+			var check models.Check
+			json.NewDecoder(w.Body).Decode(&check)
+			if check.IsUp != tt.isUp {
+				t.Errorf("Expected is_up %v, got %+v", tt.isUp, check)
+			}
+		})
+	}
+
+	checks, err := db.GetChecks(context.Background(), dbConn, 10)
+	if err != nil {
+		t.Fatalf("Failed to load checks: %v", err)
+	}
+	if len(checks) != 0 {
+		t.Errorf("Expected test checks not to be saved, got %v", checks)
+	}
+
+	req := httptest.NewRequest("POST", "/monitors/test", strings.NewReader(tests[0].body))
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a token, got %d", w.Code)
+	}
+}
+
+func TestWorker_FlappingSendsOneAlert(t *testing.T) {
+	var up atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !up.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	var mu sync.Mutex
+	var titles []string
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Embeds []struct {
+				Title string `json:"title"`
+			} `json:"embeds"`
+		}
+		json.NewDecoder(r.Body).Decode(&payload)
+		mu.Lock()
+		defer mu.Unlock()
+		for _, e := range payload.Embeds {
+			titles = append(titles, e.Title)
+		}
+	}))
+	defer hook.Close()
+
+	s, dbConn := startWorkerServer(t, monitor.Config{})
+	ctx := context.Background()
+	if _, err := db.CreateWebhook(ctx, dbConn, models.Webhook{Name: "Hook", URL: hook.URL, Enabled: true}); err != nil {
+		t.Fatalf("Failed to create webhook: %v", err)
+	}
+	// Created behind the worker's back, so only the manual checks run it.
+	id, err := db.CreateMonitor(ctx, dbConn, models.Monitor{Type: models.MonitorTypeHTTP, Name: "Flaky", URL: srv.URL, Interval: 3600})
+	if err != nil {
+		t.Fatalf("Failed to create monitor: %v", err)
+	}
+
+	// The first check only establishes the state; the next ten each change
+	// it, and the fifth change marks the monitor as flapping.
+	for i := 0; i < 11; i++ {
+		up.Store(i%2 == 0)
+		req := httptest.NewRequest("POST", fmt.Sprintf("/monitors/%d/check", id), nil)
+		req.Header.Set("Authorization", "secret")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected 200, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := notifier.Wait(waitCtx); err != nil {
+		t.Fatal(err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(titles) != 5 {
+		t.Fatalf("Expected 4 state changes and 1 flapping alert, got %q", titles)
+	}
+	flapping := 0
+	for _, title := range titles {
+		if strings.Contains(title, "Flapping") {
+			flapping++
+		}
+	}
+	if flapping != 1 {
+		t.Errorf("Expected one flapping alert, got %q", titles)
+	}
+
+	m, err := db.GetMonitor(ctx, dbConn, id)
+	if err != nil || m == nil {
+		t.Fatalf("Failed to load monitor: %v", err)
+	}
+	if !m.Flapping {
+		t.Error("Expected the monitor to be marked as flapping")
+	}
+}
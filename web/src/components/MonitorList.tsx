@@ -66,7 +66,8 @@ export const MonitorList = React.memo(function MonitorList({ monitors, checks, l
         const data = monitorHistory[m.id] || [];
         const daily = [...(dailyHistory[m.id] || [])].reverse();
         const latestCheck = checks[m.id] && checks[m.id].length > 0 ? checks[m.id][0] : null;
-        const isUp = latestCheck?.is_up ?? null;
+        const isPaused = !m.active;
+        const isUp = isPaused ? null : latestCheck?.is_up ?? null;
         const lastLatency = data.length > 0 ? data[data.length - 1].latency : 0;
         
         const avg = data.length > 0 ? data.reduce((sum, check) => sum + check.latency, 0) / data.length : 0;
@@ -87,6 +88,8 @@ export const MonitorList = React.memo(function MonitorList({ monitors, checks, l
                     <div className="text-[15px] font-bold text-foreground leading-tight flex items-center gap-2">
                       {m.name}
                       {isUp === false && <span className="text-[10px] bg-destructive/10 text-destructive px-1.5 py-0.5 rounded border border-destructive/50 uppercase tracking-wide font-bold animate-pulse">Down</span>}
+                      {!isPaused && m.flapping && <span className="text-[10px] bg-orange-500/10 text-orange-500 px-1.5 py-0.5 rounded border border-orange-500/50 uppercase tracking-wide font-bold">Flapping</span>}
+                      {isPaused && <span className="text-[10px] bg-muted text-muted-foreground px-1.5 py-0.5 rounded border border-border uppercase tracking-wide font-bold">Paused</span>}
                     </div>
                     <div className="md:hidden flex gap-2">
                       {isAdmin && (
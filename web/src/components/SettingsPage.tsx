@@ -3,7 +3,7 @@ import { MonitorForm } from './MonitorForm';
 import { MonitorList } from './MonitorList';
 import { WebhookList } from './WebhookList';
 import { IncidentList } from './IncidentList';
-import type { Monitor, Check, Incident, Webhook, DailyStats } from '@/types';
+import type { Monitor, Check, Incident, Webhook, WebhookInput, DailyStats } from '@/types';
 
 interface SettingsPageProps {
   monitors: Monitor[];
@@ -17,8 +17,8 @@ interface SettingsPageProps {
   onDeleteMonitor: (id: number) => Promise<void>;
   onAddIncident: (title: string, desc: string, status: 'investigating' | 'monitoring' | 'resolved') => Promise<boolean>;
   onDeleteIncident: (id: number) => Promise<void>;
-  onAddWebhook: (name: string, url: string) => Promise<boolean>;
-  onUpdateWebhook: (id: number, name: string, url: string, enabled: boolean) => Promise<boolean>;
+  onAddWebhook: (input: WebhookInput) => Promise<boolean>;
+  onUpdateWebhook: (id: number, input: WebhookInput, enabled: boolean) => Promise<boolean>;
   onDeleteWebhook: (id: number) => Promise<void>;
   fetchHistory: (id: number) => Promise<DailyStats[]>;
 }
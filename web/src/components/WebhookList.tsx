@@ -1,14 +1,67 @@
 import React, { useState } from 'react';
 import { Plus, Trash2, Pencil, Webhook } from 'lucide-react';
-import type { Webhook as WebhookType } from '@/types';
+import type { ChannelType, Webhook as WebhookType, WebhookInput } from '@/types';
 
 interface WebhookListProps {
   webhooks: WebhookType[];
-  onAdd: (name: string, url: string) => Promise<boolean>;
-  onUpdate: (id: number, name: string, url: string, enabled: boolean) => Promise<boolean>;
+  onAdd: (input: WebhookInput) => Promise<boolean>;
+  onUpdate: (id: number, input: WebhookInput, enabled: boolean) => Promise<boolean>;
   onDelete: (id: number) => Promise<void>;
 }
 
+const CHANNEL_TYPES: { value: ChannelType; label: string; placeholder: string }[] = [
+  { value: 'discord', label: 'Discord', placeholder: 'https://discord.com/api/webhooks/...' },
+  { value: 'slack', label: 'Slack', placeholder: 'https://hooks.slack.com/services/...' },
+  { value: 'teams', label: 'Microsoft Teams', placeholder: 'https://....webhook.office.com/...' },
+  { value: 'telegram', label: 'Telegram', placeholder: 'https://api.telegram.org (optional)' },
+  { value: 'email', label: 'Email', placeholder: '' },
+  { value: 'pagerduty', label: 'PagerDuty', placeholder: 'https://events.pagerduty.com/v2/enqueue (optional)' },
+  { value: 'opsgenie', label: 'Opsgenie', placeholder: 'https://api.opsgenie.com (optional)' },
+  { value: 'generic', label: 'Generic Webhook', placeholder: 'https://ntfy.sh/my-alerts' },
+];
+
+// Channels whose URL defaults to the provider's public API.
+const OPTIONAL_URL: ChannelType[] = ['telegram', 'pagerduty', 'opsgenie'];
+
+// Type-specific settings, shown below the name and URL.
+const CHANNEL_SETTINGS: Partial<Record<ChannelType, { key: string; placeholder: string; secret?: boolean; optional?: boolean; multiline?: boolean }[]>> = {
+  telegram: [
+    { key: 'bot_token', placeholder: 'Bot token (123456:ABC...)', secret: true },
+    { key: 'chat_id', placeholder: 'Chat ID (e.g. -1001234567890)' },
+  ],
+  email: [
+    { key: 'host', placeholder: 'SMTP host' },
+    { key: 'port', placeholder: 'Port (587)', optional: true },
+    { key: 'username', placeholder: 'Username', optional: true },
+    { key: 'password', placeholder: 'Password', secret: true, optional: true },
+    { key: 'from', placeholder: 'From (alerts@example.com)' },
+    { key: 'to', placeholder: 'To (comma-separated)' },
+  ],
+  pagerduty: [{ key: 'routing_key', placeholder: 'Integration (routing) key', secret: true }],
+  opsgenie: [{ key: 'api_key', placeholder: 'API key', secret: true }],
+  generic: [
+    { key: 'method', placeholder: 'Method (POST, PUT or PATCH)', optional: true },
+    { key: 'body', placeholder: 'Body template, e.g. {"text": {{json .Title}}} (JSON event if empty)', optional: true, multiline: true },
+  ],
+};
+
+function parseHeaders(text: string): Record<string, string> | undefined {
+  const headers: Record<string, string> = {};
+  for (const line of text.split('\n')) {
+    const i = line.indexOf(':');
+    if (i > 0) headers[line.slice(0, i).trim()] = line.slice(i + 1).trim();
+  }
+  return Object.keys(headers).length > 0 ? headers : undefined;
+}
+
+function formatHeaders(headers?: Record<string, string>): string {
+  return Object.entries(headers ?? {}).map(([k, v]) => `${k}: ${v}`).join('\n');
+}
+
+function toInput(wh: WebhookType): WebhookInput {
+  return { name: wh.name, type: wh.type, url: wh.url, settings: wh.settings, headers: wh.headers };
+}
+
 export const WebhookList = React.memo(function WebhookList({
   webhooks = [],
   onAdd,
@@ -20,47 +73,67 @@ export const WebhookList = React.memo(function WebhookList({
   const [editingId, setEditingId] = useState<number | null>(null);
   const [name, setName] = useState('');
   const [url, setUrl] = useState('');
+  const [type, setType] = useState<ChannelType>('discord');
+  const [settings, setSettings] = useState<Record<string, string>>({});
+  const [headers, setHeaders] = useState('');
+
+  const resetForm = (wh?: WebhookType) => {
+    setName(wh?.name ?? '');
+    setUrl(wh?.url ?? '');
+    setType(wh?.type ?? 'discord');
+    setSettings(wh?.settings ?? {});
+    setHeaders(formatHeaders(wh?.headers));
+  };
 
   const openAdd = () => {
     setEditingId(null);
-    setName('');
-    setUrl('');
+    resetForm();
     setShowForm(true);
   };
 
   const openEdit = (wh: WebhookType) => {
     setEditingId(wh.id);
-    setName(wh.name);
-    setUrl(wh.url);
+    resetForm(wh);
     setShowForm(true);
   };
 
   const handleCancel = () => {
     setShowForm(false);
     setEditingId(null);
-    setName('');
-    setUrl('');
+    resetForm();
   };
 
   const handleSubmit = async (e: React.FormEvent) => {
     e.preventDefault();
+    const input: WebhookInput = { name, type, url: type === 'email' ? '' : url };
+    const fields = CHANNEL_SETTINGS[type];
+    if (fields) {
+      input.settings = {};
+      for (const f of fields) {
+        if (settings[f.key]) input.settings[f.key] = settings[f.key];
+      }
+      if (type === 'email' && settings.starttls === 'false') input.settings.starttls = 'false';
+    }
+    if (type === 'generic') input.headers = parseHeaders(headers);
     let success: boolean;
     if (editingId !== null) {
       const current = webhooks.find(w => w.id === editingId);
-      success = await onUpdate(editingId, name, url, current?.enabled ?? true);
+      success = await onUpdate(editingId, input, current?.enabled ?? true);
     } else {
-      success = await onAdd(name, url);
+      success = await onAdd(input);
     }
     if (success) handleCancel();
   };
 
+  const inputClass = 'bg-background border border-border px-3 py-2 rounded text-sm text-foreground placeholder:text-muted-foreground focus:outline-none focus:ring-2 focus:ring-ring focus:border-transparent';
+
   return (
     <div className="mb-6">
       <div className="flex items-center justify-between mb-3">
         <div className="flex items-center gap-2">
           <Webhook size={14} className="text-muted-foreground" />
           <span className="text-xs font-bold uppercase tracking-wider text-muted-foreground">
-            Discord Webhooks
+            Notification Channels
           </span>
           <span className="text-xs text-muted-foreground">({webhooks.length})</span>
         </div>
@@ -68,7 +141,7 @@ export const WebhookList = React.memo(function WebhookList({
           onClick={openAdd}
           className="flex items-center gap-1 text-xs font-bold text-primary hover:opacity-80 transition-opacity"
         >
-          <Plus size={12} /> Add Webhook
+          <Plus size={12} /> Add Channel
         </button>
       </div>
 
@@ -79,7 +152,7 @@ export const WebhookList = React.memo(function WebhookList({
         >
           <div className="flex items-center justify-between mb-3">
             <span className="text-xs font-bold uppercase tracking-wider text-foreground">
-              {editingId !== null ? 'Edit Webhook' : 'New Webhook'}
+              {editingId !== null ? 'Edit Channel' : 'New Channel'}
             </span>
             <button
               type="button"
@@ -89,30 +162,83 @@ export const WebhookList = React.memo(function WebhookList({
               Cancel
             </button>
           </div>
-          <div className="grid grid-cols-1 md:grid-cols-[1fr_2fr] gap-3">
+          <div className="grid grid-cols-1 md:grid-cols-[1fr_1fr_2fr] gap-3">
+            <select
+              className={inputClass}
+              value={type}
+              onChange={e => setType(e.target.value as ChannelType)}
+            >
+              {CHANNEL_TYPES.map(t => (
+                <option key={t.value} value={t.value}>{t.label}</option>
+              ))}
+            </select>
             <input
               type="text"
               placeholder="Name (e.g. #alerts)"
-              className="bg-background border border-border px-3 py-2 rounded text-sm text-foreground placeholder:text-muted-foreground focus:outline-none focus:ring-2 focus:ring-ring focus:border-transparent"
+              className={inputClass}
               value={name}
               onChange={e => setName(e.target.value)}
               required
             />
-            <input
-              type="url"
-              placeholder="https://discord.com/api/webhooks/..."
-              className="bg-background border border-border px-3 py-2 rounded text-sm text-foreground placeholder:text-muted-foreground focus:outline-none focus:ring-2 focus:ring-ring focus:border-transparent"
-              value={url}
-              onChange={e => setUrl(e.target.value)}
-              required
-            />
+            {type !== 'email' && (
+              <input
+                type="url"
+                placeholder={CHANNEL_TYPES.find(t => t.value === type)?.placeholder}
+                className={inputClass}
+                value={url}
+                onChange={e => setUrl(e.target.value)}
+                required={!OPTIONAL_URL.includes(type)}
+              />
+            )}
           </div>
+          {CHANNEL_SETTINGS[type] && (
+            <div className="grid grid-cols-1 md:grid-cols-2 gap-3 mt-3">
+              {CHANNEL_SETTINGS[type]!.map(f => f.multiline ? (
+                <textarea
+                  key={f.key}
+                  placeholder={f.placeholder}
+                  className={`${inputClass} md:col-span-2 font-mono min-h-24`}
+                  value={settings[f.key] ?? ''}
+                  onChange={e => setSettings(s => ({ ...s, [f.key]: e.target.value }))}
+                  required={!f.optional}
+                />
+              ) : (
+                <input
+                  key={f.key}
+                  type={f.secret ? 'password' : 'text'}
+                  placeholder={f.placeholder}
+                  className={inputClass}
+                  value={settings[f.key] ?? ''}
+                  onChange={e => setSettings(s => ({ ...s, [f.key]: e.target.value }))}
+                  required={!f.optional}
+                />
+              ))}
+              {type === 'generic' && (
+                <textarea
+                  placeholder={'Headers, one per line (Authorization: Bearer ...)'}
+                  className={`${inputClass} md:col-span-2 font-mono min-h-16`}
+                  value={headers}
+                  onChange={e => setHeaders(e.target.value)}
+                />
+              )}
+              {type === 'email' && (
+                <label className="flex items-center gap-2 text-xs text-muted-foreground">
+                  <input
+                    type="checkbox"
+                    checked={settings.starttls !== 'false'}
+                    onChange={e => setSettings(s => ({ ...s, starttls: e.target.checked ? 'true' : 'false' }))}
+                  />
+                  Require STARTTLS
+                </label>
+              )}
+            </div>
+          )}
           <div className="flex justify-end mt-3">
             <button
               type="submit"
               className="bg-primary hover:opacity-90 text-primary-foreground px-4 py-2 rounded text-sm font-bold transition-opacity"
             >
-              {editingId !== null ? 'Update Webhook' : 'Add Webhook'}
+              {editingId !== null ? 'Update Channel' : 'Add Channel'}
             </button>
           </div>
         </form>
@@ -120,7 +246,7 @@ export const WebhookList = React.memo(function WebhookList({
 
       {safeWebhooks.length === 0 && !showForm ? (
         <p className="text-xs text-muted-foreground py-3">
-          No webhooks configured. Add one to receive Discord alerts on monitor state changes.
+          No channels configured. Add one to receive alerts on monitor state changes.
         </p>
       ) : (
         <div className="space-y-2">
@@ -131,7 +257,7 @@ export const WebhookList = React.memo(function WebhookList({
             >
               {/* Toggle */}
               <button
-                onClick={() => onUpdate(wh.id, wh.name, wh.url, !wh.enabled)}
+                onClick={() => onUpdate(wh.id, toInput(wh), !wh.enabled)}
                 title={wh.enabled ? 'Disable webhook' : 'Enable webhook'}
                 className={`w-8 h-4 rounded-full relative transition-colors flex-shrink-0 ${
                   wh.enabled ? 'bg-green-500' : 'bg-muted'
@@ -150,9 +276,14 @@ export const WebhookList = React.memo(function WebhookList({
                 {wh.name}
               </span>
 
+              {/* Type */}
+              <span className="text-[10px] font-bold uppercase px-2 py-0.5 rounded bg-muted text-muted-foreground flex-shrink-0">
+                {CHANNEL_TYPES.find(t => t.value === wh.type)?.label ?? wh.type}
+              </span>
+
               {/* URL (masked) */}
               <span className="text-xs text-muted-foreground font-mono flex-1 truncate">
-                {maskUrl(wh.url)}
+                {wh.type === 'email' ? wh.settings?.to : maskUrl(wh.url)}
               </span>
 
               {/* Status pill */}
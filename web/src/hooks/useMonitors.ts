@@ -1,6 +1,6 @@
 import { useState, useEffect, useMemo, useCallback } from 'react';
 import axios from 'axios';
-import type { Monitor, Check, MonitorStats, Incident, DailyStats, Webhook, ApiError } from '@/types';
+import type { Monitor, Check, MonitorStats, Incident, DailyStats, Webhook, WebhookInput, ApiError } from '@/types';
 import { toast, confirmAction, handleApiError } from '@/utils/notifications';
 
 const API_BASE = import.meta.env.VITE_API_BASE || '';
@@ -19,8 +19,8 @@ interface UseMonitorsReturn {
   deleteMonitor: (id: number) => Promise<void>;
   addIncident: (title: string, description: string, status: 'investigating' | 'monitoring' | 'resolved') => Promise<boolean>;
   deleteIncident: (id: number) => Promise<void>;
-  addWebhook: (name: string, url: string) => Promise<boolean>;
-  updateWebhook: (id: number, name: string, url: string, enabled: boolean) => Promise<boolean>;
+  addWebhook: (input: WebhookInput) => Promise<boolean>;
+  updateWebhook: (id: number, input: WebhookInput, enabled: boolean) => Promise<boolean>;
   deleteWebhook: (id: number) => Promise<void>;
   verifyToken: (token: string) => Promise<boolean>;
   getMonitorHistory: (id: number) => Promise<DailyStats[]>;
@@ -198,9 +198,9 @@ export function useMonitors(): UseMonitorsReturn {
     }
   }, [token, fetchData]);
 
-  const addWebhook = useCallback(async (name: string, url: string) => {
+  const addWebhook = useCallback(async (input: WebhookInput) => {
     try {
-      await axios.post(`${API_BASE}/webhooks`, { name, url }, {
+      await axios.post(`${API_BASE}/webhooks`, input, {
         headers: { Authorization: token }
       });
       fetchData();
@@ -218,9 +218,9 @@ export function useMonitors(): UseMonitorsReturn {
     }
   }, [token, fetchData]);
 
-  const updateWebhook = useCallback(async (id: number, name: string, url: string, enabled: boolean) => {
+  const updateWebhook = useCallback(async (id: number, input: WebhookInput, enabled: boolean) => {
     try {
-      await axios.put(`${API_BASE}/webhooks/${id}`, { name, url, enabled }, {
+      await axios.put(`${API_BASE}/webhooks/${id}`, { ...input, enabled }, {
         headers: { Authorization: token }
       });
       fetchData();
@@ -1,18 +1,75 @@
+export type MonitorType = 'http' | 'tcp' | 'dns' | 'push';
+
+export type MonitorStatus = 'up' | 'down' | '';
+
 export interface Monitor {
   id: number;
   name: string;
+  type: MonitorType;
   url: string;
   interval: number;
+  timeout?: number;
+  confirm_after?: number;
+  retry_interval?: number;
+  method?: 'GET' | 'HEAD' | 'POST' | 'PUT' | 'PATCH' | 'DELETE' | 'OPTIONS';
+  headers?: Record<string, string>;
+  body?: string;
+  accepted_status_codes?: string;
+  keyword?: string;
+  invert_keyword?: boolean;
+  body_regex?: string;
+  json_assertions?: string[];
+  expected_banner?: string;
+  dns_record_type?: 'A' | 'AAAA' | 'CNAME' | 'MX' | 'TXT';
+  dns_resolver?: string;
+  dns_expected?: string;
+  push_token?: string;
+  grace_period?: number;
+  parent_id?: number;
+  last_push_at?: string | null;
   last_checked_at: string | null;
+  active: boolean;
+  status: MonitorStatus;
+  status_since?: string;
+  consecutive_failures: number;
+  flapping: boolean;
+}
+
+export interface Timings {
+  dns: number;
+  connect: number;
+  tls: number;
+  ttfb: number;
+  transfer: number;
 }
 
 export interface Check {
   monitor_id: number;
   latency: number;
+  timings: Timings;
   is_up: boolean;
+  assertion?: string;
+  error_category?: string;
+  error_message?: string;
+  maintenance?: boolean;
+  checked_at: string;
+}
+
+export interface Certificate {
+  monitor_id: number;
+  subject: string;
+  issuer: string;
+  sans: string[];
+  not_before: string;
+  not_after: string;
   checked_at: string;
 }
 
+export interface CheckResult extends Check {
+  status_code?: number;
+  certificate?: Certificate;
+}
+
 export interface MonitorStats {
   total: number;
   up: number;
@@ -32,15 +89,57 @@ export interface DailyStats {
   date: string;
   uptime_pct: number;
   avg_latency: number;
+  avg_timings: Timings;
+  maintenance_count: number;
 }
 
+export interface MaintenanceWindow {
+  id: number;
+  title: string;
+  timezone: string;
+  starts_at?: string;
+  ends_at?: string;
+  weekdays?: number[];
+  start_time?: string;
+  duration?: number;
+  monitor_ids: number[];
+}
+
+export type ChannelType = 'discord' | 'slack' | 'teams' | 'telegram' | 'email' | 'pagerduty' | 'opsgenie' | 'generic';
+
 export interface Webhook {
   id: number;
   name: string;
+  type: ChannelType;
   url: string;
+  settings?: Record<string, string>;
+  headers?: Record<string, string>;
   enabled: boolean;
 }
 
+export type WebhookInput = Pick<Webhook, 'name' | 'type' | 'url' | 'settings' | 'headers'>;
+
+export interface DeliveryAttempt {
+  attempted_at: string;
+  status_code?: number;
+  error?: string;
+  duration_ms: number;
+}
+
+export interface Delivery {
+  id: number;
+  webhook_id: number;
+  event: string;
+  monitor_id: number;
+  status: 'pending' | 'delivered' | 'failed' | 'superseded';
+  attempt_count: number;
+  next_attempt_at?: string;
+  last_status_code?: number;
+  last_error?: string;
+  created_at: string;
+  attempts: DeliveryAttempt[];
+}
+
 export interface ApiError {
   response?: {
     status: number;